@@ -0,0 +1,88 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/puller-io/puller/cmd/puller/app/options"
+	"github.com/puller-io/puller/pkg/controller/puller"
+)
+
+// configReloadPeriod is the interval between two checks of the configuration file.
+const configReloadPeriod = 10 * time.Second
+
+// configReloader applies the settings of the configuration file that are
+// safe to change at runtime, whenever the file changes. The file is polled,
+// so that the updates of a mounted ConfigMap are seen as well.
+type configReloader struct {
+	opts         *options.Options
+	controller   *puller.Controller
+	writeLimiter *rate.Limiter
+	sweeper      *puller.OrphanSweeper
+
+	content []byte
+}
+
+// Start checks the configuration file until the context is done.
+func (r *configReloader) Start(ctx context.Context) error {
+	content, err := os.ReadFile(r.opts.ConfigFile)
+	if err != nil {
+		klog.Errorf("Failed to read config file %s: %v", r.opts.ConfigFile, err)
+	}
+	r.content = content
+	wait.UntilWithContext(ctx, r.reload, configReloadPeriod)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (r *configReloader) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *configReloader) reload(ctx context.Context) {
+	content, err := os.ReadFile(r.opts.ConfigFile)
+	if err != nil {
+		klog.Errorf("Failed to read config file %s: %v", r.opts.ConfigFile, err)
+		return
+	}
+	if bytes.Equal(content, r.content) {
+		return
+	}
+	r.content = content
+
+	cfg, err := options.LoadConfiguration(r.opts.ConfigFile)
+	if err != nil {
+		klog.Errorf("Failed to reload config file: %v", err)
+		return
+	}
+	opts := r.opts.Reloadable(cfg)
+	if errs := opts.Validate(); len(errs) != 0 {
+		klog.Errorf("Invalid config file %s, keep the current settings: %v", r.opts.ConfigFile, errs.ToAggregate())
+		return
+	}
+
+	klog.InfoS("Reloaded config file", "path", r.opts.ConfigFile, "fanOutWorkers", opts.FanOutWorkers,
+		"maxWritesPerSecond", opts.MaxWritesPerSecond, "writesBurst", opts.WritesBurst, "orphanSweepMode", opts.OrphanSweepMode)
+	r.controller.SetFanOutWorkers(opts.FanOutWorkers)
+	r.writeLimiter.SetLimit(writeLimit(opts.MaxWritesPerSecond))
+	r.writeLimiter.SetBurst(opts.WritesBurst)
+	if r.sweeper != nil {
+		r.sweeper.SetDelete(opts.OrphanSweepMode == options.OrphanSweepModeDelete && !opts.DryRun)
+	}
+	r.opts = opts
+}
+
+// writeLimit converts the maximum number of writes per second to a rate
+// limit, zero means no limit.
+func writeLimit(maxWritesPerSecond float64) rate.Limit {
+	if maxWritesPerSecond <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(maxWritesPerSecond)
+}
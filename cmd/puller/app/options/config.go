@@ -0,0 +1,120 @@
+package options
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	configv1alpha1 "github.com/puller-io/puller/pkg/apis/config/v1alpha1"
+)
+
+var (
+	configScheme = runtime.NewScheme()
+	configCodecs = serializer.NewCodecFactory(configScheme, serializer.EnableStrict)
+)
+
+func init() {
+	utilruntime.Must(configv1alpha1.AddToScheme(configScheme))
+}
+
+// LoadConfiguration reads a PullerControllerConfiguration file, unknown
+// and duplicated fields are rejected.
+func LoadConfiguration(path string) (*configv1alpha1.PullerControllerConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	obj, gvk, err := configCodecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	cfg, ok := obj.(*configv1alpha1.PullerControllerConfiguration)
+	if !ok {
+		return nil, fmt.Errorf("%s is a %s, not a PullerControllerConfiguration", path, gvk)
+	}
+	return cfg, nil
+}
+
+// ApplyConfiguration sets the options from the configuration file, the
+// options set by a flag keep the value of the flag.
+func (o *Options) ApplyConfiguration(cfg *configv1alpha1.PullerControllerConfiguration) {
+	if o.startup == nil {
+		o.startup = o.copy()
+	}
+	set := func(flag string, isSet bool, apply func()) {
+		if isSet && (o.flags == nil || !o.flags.Changed(flag)) {
+			apply()
+		}
+	}
+
+	if le := cfg.LeaderElection; le != nil {
+		set("leader-elect", le.LeaderElect != nil, func() { o.LeaderElection.LeaderElect = *le.LeaderElect })
+		set("leader-elect-lease-duration", le.LeaseDuration.Duration != 0, func() { o.LeaderElection.LeaseDuration = le.LeaseDuration })
+		set("leader-elect-renew-deadline", le.RenewDeadline.Duration != 0, func() { o.LeaderElection.RenewDeadline = le.RenewDeadline })
+		set("leader-elect-retry-period", le.RetryPeriod.Duration != 0, func() { o.LeaderElection.RetryPeriod = le.RetryPeriod })
+		set("leader-elect-resource-lock", le.ResourceLock != "", func() { o.LeaderElection.ResourceLock = le.ResourceLock })
+		set("leader-elect-resource-name", le.ResourceName != "", func() { o.LeaderElection.ResourceName = le.ResourceName })
+		set("leader-elect-resource-namespace", le.ResourceNamespace != "", func() { o.LeaderElection.ResourceNamespace = le.ResourceNamespace })
+	}
+	set("metrics-bind-address", cfg.MetricsBindAddress != nil, func() { o.MetricsAddr = *cfg.MetricsBindAddress })
+	set("health-probe-bind-address", cfg.HealthProbeBindAddress != nil, func() { o.ProbeAddr = *cfg.HealthProbeBindAddress })
+	set("kube-api-qps", cfg.KubeAPIQPS != nil, func() { o.KubeAPIQPS = *cfg.KubeAPIQPS })
+	set("kube-api-burst", cfg.KubeAPIBurst != nil, func() { o.KubeAPIBurst = int(*cfg.KubeAPIBurst) })
+	if cfg.ResyncPeriod != nil {
+		// the resync period has no flag
+		o.ResyncPeriod = *cfg.ResyncPeriod
+	}
+	set("concurrent-puller-syncs", cfg.ConcurrentPullerSyncs != nil, func() { o.ConcurrentPullerSyncs = int(*cfg.ConcurrentPullerSyncs) })
+	set("concurrent-namespace-syncs", cfg.ConcurrentNamespaceSyncs != nil, func() { o.ConcurrentNamespaceSyncs = int(*cfg.ConcurrentNamespaceSyncs) })
+	set("fan-out-workers", cfg.FanOutWorkers != nil, func() { o.FanOutWorkers = int(*cfg.FanOutWorkers) })
+	set("max-writes-per-second", cfg.MaxWritesPerSecond != nil, func() { o.MaxWritesPerSecond = *cfg.MaxWritesPerSecond })
+	set("writes-burst", cfg.WritesBurst != nil, func() { o.WritesBurst = int(*cfg.WritesBurst) })
+	set("orphan-sweep-period", cfg.OrphanSweepPeriod != nil, func() { o.OrphanSweepPeriod = *cfg.OrphanSweepPeriod })
+	set("orphan-sweep-mode", cfg.OrphanSweepMode != nil, func() { o.OrphanSweepMode = *cfg.OrphanSweepMode })
+	set("workqueue-stall-timeout", cfg.WorkqueueStallTimeout != nil, func() { o.WorkqueueStallTimeout = *cfg.WorkqueueStallTimeout })
+	set("enable-debug-endpoint", cfg.EnableDebugEndpoint != nil, func() { o.EnableDebugEndpoint = *cfg.EnableDebugEndpoint })
+	set("dry-run", cfg.DryRun != nil, func() { o.DryRun = *cfg.DryRun })
+	set("watch-namespaces", cfg.WatchNamespaces != nil, func() { o.WatchNamespaces = cfg.WatchNamespaces })
+	set("namespace-label-selector", cfg.NamespaceLabelSelector != nil, func() { o.NamespaceLabelSelector = *cfg.NamespaceLabelSelector })
+	set("enable-webhooks", cfg.EnableWebhooks != nil, func() { o.EnableWebhooks = *cfg.EnableWebhooks })
+	set("webhook-port", cfg.WebhookPort != nil, func() { o.WebhookPort = int(*cfg.WebhookPort) })
+	set("webhook-cert-dir", cfg.WebhookCertDir != nil, func() { o.WebhookCertDir = *cfg.WebhookCertDir })
+	set("webhook-service-name", cfg.WebhookServiceName != nil, func() { o.WebhookServiceName = *cfg.WebhookServiceName })
+	set("webhook-service-namespace", cfg.WebhookServiceNamespace != nil, func() { o.WebhookServiceNamespace = *cfg.WebhookServiceNamespace })
+	set("history-namespace", cfg.HistoryNamespace != nil, func() { o.HistoryNamespace = *cfg.HistoryNamespace })
+	set("migrate-storage-version", cfg.MigrateStorageVersion != nil, func() { o.MigrateStorageVersion = *cfg.MigrateStorageVersion })
+	set("tracing-endpoint", cfg.TracingEndpoint != nil, func() { o.TracingEndpoint = *cfg.TracingEndpoint })
+	set("tracing-insecure", cfg.TracingInsecure != nil, func() { o.TracingInsecure = *cfg.TracingInsecure })
+	set("tracing-sampling-ratio", cfg.TracingSamplingRatio != nil, func() { o.TracingSamplingRatio = *cfg.TracingSamplingRatio })
+	set("shards", cfg.Shards != nil, func() { o.Shards = int(*cfg.Shards) })
+}
+
+// Reloadable returns a copy of the options, where only the options that are
+// safe to change at runtime are taken from the configuration file. They are
+// applied to the options of the flags, so that an option removed from the
+// file is back to the value of its flag.
+func (o *Options) Reloadable(cfg *configv1alpha1.PullerControllerConfiguration) *Options {
+	startup := o.startup
+	if startup == nil {
+		startup = o
+	}
+	loaded := startup.copy()
+	loaded.ApplyConfiguration(cfg)
+
+	reloaded := *o
+	reloaded.FanOutWorkers = loaded.FanOutWorkers
+	reloaded.MaxWritesPerSecond = loaded.MaxWritesPerSecond
+	reloaded.WritesBurst = loaded.WritesBurst
+	reloaded.OrphanSweepMode = loaded.OrphanSweepMode
+	return &reloaded
+}
+
+// copy returns a copy of the options that shares none of their slices.
+func (o *Options) copy() *Options {
+	copied := *o
+	copied.WatchNamespaces = append([]string(nil), o.WatchNamespaces...)
+	return &copied
+}
@@ -0,0 +1,102 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestReloadable(t *testing.T) {
+	writeConfig := func(t *testing.T, path, fields string) {
+		t.Helper()
+		content := "apiVersion: config.puller.io/v1alpha1\nkind: PullerControllerConfiguration\n" + fields
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newOptions := func(t *testing.T, args ...string) *Options {
+		t.Helper()
+		o := NewOptions()
+		fs := pflag.NewFlagSet("puller", pflag.ContinueOnError)
+		o.AddFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return o
+	}
+
+	tests := []struct {
+		name               string
+		args               []string
+		initial, reloaded  string
+		wantInitialWorkers int
+		wantWorkers        int
+		wantMode           string
+	}{
+		{
+			name:               "removed field reverts to the default",
+			initial:            "fanOutWorkers: 3\norphanSweepMode: Delete\n",
+			reloaded:           "",
+			wantInitialWorkers: 3,
+			wantWorkers:        10,
+			wantMode:           OrphanSweepModeReport,
+		},
+		{
+			name:               "removed field reverts to the flag",
+			args:               []string{"--orphan-sweep-mode=Delete"},
+			initial:            "fanOutWorkers: 3\n",
+			reloaded:           "",
+			wantInitialWorkers: 3,
+			wantWorkers:        10,
+			wantMode:           OrphanSweepModeDelete,
+		},
+		{
+			name:               "changed field is applied",
+			initial:            "fanOutWorkers: 3\n",
+			reloaded:           "fanOutWorkers: 5\n",
+			wantInitialWorkers: 3,
+			wantWorkers:        5,
+			wantMode:           OrphanSweepModeReport,
+		},
+		{
+			name:               "flag overrides the file",
+			args:               []string{"--fan-out-workers=7"},
+			initial:            "fanOutWorkers: 3\n",
+			reloaded:           "fanOutWorkers: 5\n",
+			wantInitialWorkers: 7,
+			wantWorkers:        7,
+			wantMode:           OrphanSweepModeReport,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			o := newOptions(t, tt.args...)
+			writeConfig(t, path, tt.initial)
+			cfg, err := LoadConfiguration(path)
+			if err != nil {
+				t.Fatalf("LoadConfiguration() error = %v", err)
+			}
+			o.ApplyConfiguration(cfg)
+			if o.FanOutWorkers != tt.wantInitialWorkers {
+				t.Fatalf("FanOutWorkers = %d, want %d", o.FanOutWorkers, tt.wantInitialWorkers)
+			}
+
+			writeConfig(t, path, tt.reloaded)
+			cfg, err = LoadConfiguration(path)
+			if err != nil {
+				t.Fatalf("LoadConfiguration() error = %v", err)
+			}
+			// reload twice, the second reload starts from the first one
+			reloaded := o.Reloadable(cfg).Reloadable(cfg)
+			if reloaded.FanOutWorkers != tt.wantWorkers {
+				t.Errorf("reloaded FanOutWorkers = %d, want %d", reloaded.FanOutWorkers, tt.wantWorkers)
+			}
+			if reloaded.OrphanSweepMode != tt.wantMode {
+				t.Errorf("reloaded OrphanSweepMode = %q, want %q", reloaded.OrphanSweepMode, tt.wantMode)
+			}
+		})
+	}
+}
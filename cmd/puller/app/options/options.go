@@ -11,6 +11,9 @@ import (
 )
 
 type Options struct {
+	// ConfigFile is the path of a PullerControllerConfiguration file, the
+	// flags override its values.
+	ConfigFile string
 	// LeaderElection defines the configuration of leader election client.
 	LeaderElection componentbaseconfig.LeaderElectionConfiguration
 	// metricsAddr define the metrics addr
@@ -29,8 +32,83 @@ type Options struct {
 	// ConcurrentPullerSyncs is the number of puller objects that are
 	// allowed to sync concurrently.
 	ConcurrentPullerSyncs int
+	// ConcurrentNamespaceSyncs is the number of puller and namespace pairs
+	// that are allowed to sync concurrently on namespace and secret events.
+	ConcurrentNamespaceSyncs int
+	// FanOutWorkers is the number of namespaces a puller syncs in parallel.
+	FanOutWorkers int
+	// MaxWritesPerSecond limits the writes to the target namespaces, zero
+	// means no limit.
+	MaxWritesPerSecond float64
+	// WritesBurst is the number of writes allowed at once over MaxWritesPerSecond.
+	WritesBurst int
+	// WatchNamespaces restricts the controller to these namespaces, empty
+	// means every namespace.
+	WatchNamespaces []string
+	// NamespaceLabelSelector restricts the controller to the namespaces it
+	// selects.
+	NamespaceLabelSelector string
+	// Shards is the number of shards the pullers are split into. Every
+	// replica reconciles the pullers of the shards it holds a lease for,
+	// zero disables sharding.
+	Shards int
+	// OrphanSweepPeriod is the interval between two sweeps of orphaned
+	// secrets and service account references. Zero disables the sweeper.
+	OrphanSweepPeriod metav1.Duration
+	// OrphanSweepMode decides whether orphans are deleted or only reported.
+	OrphanSweepMode string
+	// WorkqueueStallTimeout is the time a workqueue with queued items may go
+	// without finishing any of them before the liveness check fails. Every
+	// namespace synced by a puller reconcile is progress. Zero disables the
+	// check.
+	WorkqueueStallTimeout metav1.Duration
+	// EnableDebugEndpoint serves the distribution state of the pullers on
+	// /debug/puller of the metrics endpoint.
+	EnableDebugEndpoint bool
+	// DryRun puts every puller in plan mode, the controller only publishes
+	// the changes it would make.
+	DryRun bool
+	// EnableWebhooks serves the conversion, defaulting and validating
+	// webhooks of the pullers. The Puller CRD converts its versions with
+	// the webhook, so it is enabled by default.
+	EnableWebhooks bool
+	// WebhookPort is the port the webhook server listens on.
+	WebhookPort int
+	// WebhookCertDir holds the tls.crt and tls.key of the webhook server.
+	WebhookCertDir string
+	// WebhookServiceName and WebhookServiceNamespace locate the service of
+	// the webhook server, the serving certificate is issued for it.
+	WebhookServiceName      string
+	WebhookServiceNamespace string
+	// MigrateStorageVersion rewrites the pullers stored in a previous
+	// version in the storage version.
+	MigrateStorageVersion bool
+	// HistoryNamespace holds the revisions of the credentials of the
+	// pullers, empty, the default, disables the history.
+	HistoryNamespace string
+	// TracingEndpoint is the host:port of the OTLP gRPC collector the traces
+	// are exported to, empty disables tracing.
+	TracingEndpoint string
+	// TracingInsecure disables TLS to the collector.
+	TracingInsecure bool
+	// TracingSamplingRatio is the ratio of the reconciles that are traced.
+	TracingSamplingRatio float64
+
+	// flags are the flags bound to the options, the options set by a flag
+	// are not overridden by the configuration file.
+	flags *pflag.FlagSet
+	// startup are the options before the configuration file is applied,
+	// every reload of the file starts over from them.
+	startup *Options
 }
 
+const (
+	// OrphanSweepModeReport only reports the orphans found by the sweeper.
+	OrphanSweepModeReport = "Report"
+	// OrphanSweepModeDelete deletes the orphans found by the sweeper.
+	OrphanSweepModeDelete = "Delete"
+)
+
 func NewOptions() *Options {
 	return &Options{
 		LeaderElection: componentbaseconfig.LeaderElectionConfiguration{
@@ -49,10 +127,34 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	if o == nil {
 		return
 	}
+	o.flags = fs
+	fs.StringVar(&o.ConfigFile, "config", "", "The path of a PullerControllerConfiguration file. The flags set on the command line override its values. The fan-out workers, the write rate limit and the orphan sweep mode are reloaded when the file changes.")
 	fs.StringVar(&o.MetricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	fs.StringVar(&o.ProbeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	fs.Float32Var(&o.KubeAPIQPS, "kube-api-qps", 40.0, "QPS to use while talking with karmada-apiserver. Doesn't cover events and node heartbeat apis which rate limiting is controlled by a different set of flags.")
 	fs.IntVar(&o.KubeAPIBurst, "kube-api-burst", 60, "Burst to use while talking with karmada-apiserver. Doesn't cover events and node heartbeat apis which rate limiting is controlled by a different set of flags.")
 	fs.IntVar(&o.ConcurrentPullerSyncs, "concurrent-puller-syncs", 5, "The number of Puller that are allowed to sync concurrently.")
+	fs.IntVar(&o.ConcurrentNamespaceSyncs, "concurrent-namespace-syncs", 10, "The number of Puller and namespace pairs that are allowed to sync concurrently on namespace and secret events.")
+	fs.IntVar(&o.FanOutWorkers, "fan-out-workers", 10, "The number of namespaces a Puller syncs in parallel.")
+	fs.Float64Var(&o.MaxWritesPerSecond, "max-writes-per-second", 0, "The maximum number of writes per second to the target namespaces. Zero means no limit.")
+	fs.IntVar(&o.WritesBurst, "writes-burst", 20, "The number of writes to the target namespaces allowed at once over --max-writes-per-second.")
+	fs.StringSliceVar(&o.WatchNamespaces, "watch-namespaces", nil, "The namespaces the controller operates in, separated by commas. Empty means every namespace. With a namespace set, namespaces are neither listed nor watched, so the controller only needs namespaced permissions there; if it is not allowed to get a namespace, the namespace is treated as having no labels.")
+	fs.StringVar(&o.NamespaceLabelSelector, "namespace-label-selector", "", "A label selector restricting the namespaces the controller operates in. Empty means every namespace.")
+	fs.IntVar(&o.Shards, "shards", 0, "The number of shards the Pullers are split into. Every replica reconciles the Pullers of the shards it holds a lease for, the shards are rebalanced when replicas join or leave. Zero disables sharding, --leader-elect then picks a single replica for every Puller. The shard leases use --leader-elect-resource-namespace, --leader-elect-resource-name as prefix, --leader-elect-lease-duration and --leader-elect-retry-period.")
+	fs.DurationVar(&o.OrphanSweepPeriod.Duration, "orphan-sweep-period", 10*time.Minute, "The interval between two sweeps of orphaned secrets and service account references. Zero disables the sweeper.")
+	fs.StringVar(&o.OrphanSweepMode, "orphan-sweep-mode", OrphanSweepModeReport, "What to do with the orphans found by the sweeper, one of Report or Delete.")
+	fs.DurationVar(&o.WorkqueueStallTimeout.Duration, "workqueue-stall-timeout", 10*time.Minute, "The time a workqueue with queued items may go without finishing any of them before the liveness check fails. A Puller reconcile makes progress with every namespace it syncs, so the timeout must exceed the sync of a single namespace, a few writes under --max-writes-per-second, rather than the whole fan-out. Zero disables the check.")
+	fs.BoolVar(&o.EnableDebugEndpoint, "enable-debug-endpoint", false, "Serve the distribution state of every Puller as JSON on /debug/puller of the metrics endpoint.")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "Only compute the changes for every Puller and publish them in its status, without writing to the target namespaces.")
+	fs.BoolVar(&o.EnableWebhooks, "enable-webhooks", true, "Serve the conversion, defaulting and validating webhooks of the Pullers. The serving certificate is issued by the controller and its CA is injected into the webhook configurations and the Puller CRD. The Puller CRD converts its versions with this webhook: without it the v1alpha1 Pullers are not served and --migrate-storage-version must be disabled.")
+	fs.IntVar(&o.WebhookPort, "webhook-port", 9443, "The port the webhook server listens on.")
+	fs.StringVar(&o.WebhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs", "The directory holding the tls.crt and tls.key of the webhook server.")
+	fs.StringVar(&o.WebhookServiceName, "webhook-service-name", "puller-webhook", "The name of the service of the webhook server.")
+	fs.StringVar(&o.WebhookServiceNamespace, "webhook-service-namespace", "puller", "The namespace of the service of the webhook server, which also holds the secret of its certificate.")
+	fs.BoolVar(&o.MigrateStorageVersion, "migrate-storage-version", true, "Rewrite the Pullers stored in a previous version of the API in the storage version, so that the previous version can be removed. The Pullers are converted by the webhook, so it requires --enable-webhooks.")
+	fs.StringVar(&o.HistoryNamespace, "history-namespace", "", "The namespace holding the revisions of the credentials of every Puller, which spec.rollbackTo distributes again, usually the namespace of the controller. Empty disables the history, as does a namespace that does not exist.")
+	fs.StringVar(&o.TracingEndpoint, "tracing-endpoint", "", "The host:port of the OTLP gRPC collector the traces of the reconciles are exported to. Empty disables tracing.")
+	fs.BoolVar(&o.TracingInsecure, "tracing-insecure", false, "Connect to the OTLP collector without TLS.")
+	fs.Float64Var(&o.TracingSamplingRatio, "tracing-sampling-ratio", 1, "The ratio of the reconciles that are traced, between 0 and 1.")
 	options.BindLeaderElectionFlags(&o.LeaderElection, fs)
 }
@@ -1,11 +1,143 @@
 package options
 
 import (
+	"net"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 // Validate checks Options and return a slice of found errs.
 func (o *Options) Validate() field.ErrorList {
 	errs := field.ErrorList{}
+	newPath := field.NewPath("Options")
+
+	if o.KubeAPIQPS <= 0 {
+		errs = append(errs, field.Invalid(newPath.Child("KubeAPIQPS"), o.KubeAPIQPS, "must be greater than 0"))
+	}
+	if o.KubeAPIBurst <= 0 {
+		errs = append(errs, field.Invalid(newPath.Child("KubeAPIBurst"), o.KubeAPIBurst, "must be greater than 0"))
+	} else if float32(o.KubeAPIBurst) < o.KubeAPIQPS {
+		errs = append(errs, field.Invalid(newPath.Child("KubeAPIBurst"), o.KubeAPIBurst, "must be greater than or equal to KubeAPIQPS"))
+	}
+	if o.LeaderElection.LeaderElect || o.Shards > 0 {
+		errs = append(errs, o.validateLeaderElection(newPath.Child("LeaderElection"))...)
+	}
+	errs = append(errs, validateBindAddress(newPath.Child("MetricsAddr"), o.MetricsAddr)...)
+	errs = append(errs, validateBindAddress(newPath.Child("ProbeAddr"), o.ProbeAddr)...)
+	if o.ResyncPeriod.Duration < 0 {
+		errs = append(errs, field.Invalid(newPath.Child("ResyncPeriod"), o.ResyncPeriod.Duration.String(), "must be greater than or equal to 0"))
+	}
+	if o.ConcurrentPullerSyncs < 1 {
+		errs = append(errs, field.Invalid(newPath.Child("ConcurrentPullerSyncs"), o.ConcurrentPullerSyncs, "must be greater than 0"))
+	}
+	if o.ConcurrentNamespaceSyncs < 1 {
+		errs = append(errs, field.Invalid(newPath.Child("ConcurrentNamespaceSyncs"), o.ConcurrentNamespaceSyncs, "must be greater than 0"))
+	}
+	if o.OrphanSweepPeriod.Duration < 0 {
+		errs = append(errs, field.Invalid(newPath.Child("OrphanSweepPeriod"), o.OrphanSweepPeriod, "must be greater than or equal to 0"))
+	}
+	if o.OrphanSweepMode != OrphanSweepModeReport && o.OrphanSweepMode != OrphanSweepModeDelete {
+		errs = append(errs, field.NotSupported(newPath.Child("OrphanSweepMode"), o.OrphanSweepMode, []string{OrphanSweepModeReport, OrphanSweepModeDelete}))
+	}
+	if o.WorkqueueStallTimeout.Duration < 0 {
+		errs = append(errs, field.Invalid(newPath.Child("WorkqueueStallTimeout"), o.WorkqueueStallTimeout.Duration.String(), "must be greater than or equal to 0"))
+	}
+	if o.FanOutWorkers < 1 {
+		errs = append(errs, field.Invalid(newPath.Child("FanOutWorkers"), o.FanOutWorkers, "must be greater than 0"))
+	}
+	if o.MaxWritesPerSecond < 0 {
+		errs = append(errs, field.Invalid(newPath.Child("MaxWritesPerSecond"), o.MaxWritesPerSecond, "must be greater than or equal to 0"))
+	}
+	if o.MaxWritesPerSecond > 0 && o.WritesBurst < 1 {
+		errs = append(errs, field.Invalid(newPath.Child("WritesBurst"), o.WritesBurst, "must be greater than 0"))
+	}
+	if o.Shards < 0 {
+		errs = append(errs, field.Invalid(newPath.Child("Shards"), o.Shards, "must be greater than or equal to 0"))
+	}
+	if _, err := labels.Parse(o.NamespaceLabelSelector); err != nil {
+		errs = append(errs, field.Invalid(newPath.Child("NamespaceLabelSelector"), o.NamespaceLabelSelector, err.Error()))
+	}
+	for i, ns := range o.WatchNamespaces {
+		for _, msg := range validation.ValidateNamespaceName(ns, false) {
+			errs = append(errs, field.Invalid(newPath.Child("WatchNamespaces").Index(i), ns, msg))
+		}
+	}
+	if o.MigrateStorageVersion && !o.EnableWebhooks {
+		// the stored pullers of the previous version are read through the
+		// conversion webhook of the CRD
+		errs = append(errs, field.Forbidden(newPath.Child("MigrateStorageVersion"), "requires the conversion webhook, enable EnableWebhooks"))
+	}
+	if o.EnableWebhooks {
+		if o.WebhookPort < 1 || o.WebhookPort > 65535 {
+			errs = append(errs, field.Invalid(newPath.Child("WebhookPort"), o.WebhookPort, "must be between 1 and 65535"))
+		}
+		if o.WebhookCertDir == "" {
+			errs = append(errs, field.Required(newPath.Child("WebhookCertDir"), ""))
+		}
+		for _, msg := range validation.NameIsDNS1035Label(o.WebhookServiceName, false) {
+			errs = append(errs, field.Invalid(newPath.Child("WebhookServiceName"), o.WebhookServiceName, msg))
+		}
+		for _, msg := range validation.ValidateNamespaceName(o.WebhookServiceNamespace, false) {
+			errs = append(errs, field.Invalid(newPath.Child("WebhookServiceNamespace"), o.WebhookServiceNamespace, msg))
+		}
+	}
+	if o.HistoryNamespace != "" {
+		for _, msg := range validation.ValidateNamespaceName(o.HistoryNamespace, false) {
+			errs = append(errs, field.Invalid(newPath.Child("HistoryNamespace"), o.HistoryNamespace, msg))
+		}
+	}
+	if o.TracingEndpoint != "" {
+		if _, _, err := net.SplitHostPort(o.TracingEndpoint); err != nil {
+			errs = append(errs, field.Invalid(newPath.Child("TracingEndpoint"), o.TracingEndpoint, err.Error()))
+		}
+	}
+	if o.TracingSamplingRatio < 0 || o.TracingSamplingRatio > 1 {
+		errs = append(errs, field.Invalid(newPath.Child("TracingSamplingRatio"), o.TracingSamplingRatio, "must be between 0 and 1"))
+	}
+	return errs
+}
+
+// validateLeaderElection checks that a leader renews its lease before it
+// expires: lease duration > renew deadline > retry period.
+func (o *Options) validateLeaderElection(fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	le := o.LeaderElection
+	if le.RetryPeriod.Duration <= 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("RetryPeriod"), le.RetryPeriod.Duration.String(), "must be greater than 0"))
+	}
+	if le.RenewDeadline.Duration <= le.RetryPeriod.Duration {
+		errs = append(errs, field.Invalid(fldPath.Child("RenewDeadline"), le.RenewDeadline.Duration.String(), "must be greater than RetryPeriod"))
+	}
+	if le.LeaseDuration.Duration <= le.RenewDeadline.Duration {
+		errs = append(errs, field.Invalid(fldPath.Child("LeaseDuration"), le.LeaseDuration.Duration.String(), "must be greater than RenewDeadline"))
+	}
+	if le.ResourceName == "" {
+		errs = append(errs, field.Required(fldPath.Child("ResourceName"), ""))
+	}
+	for _, msg := range validation.ValidateNamespaceName(le.ResourceNamespace, false) {
+		errs = append(errs, field.Invalid(fldPath.Child("ResourceNamespace"), le.ResourceNamespace, msg))
+	}
+	return errs
+}
+
+// validateBindAddress checks a host:port bind address, "0" disables the endpoint.
+func validateBindAddress(fldPath *field.Path, address string) field.ErrorList {
+	errs := field.ErrorList{}
+	if address == "0" {
+		return errs
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return append(errs, field.Invalid(fldPath, address, err.Error()))
+	}
+	if host != "" && net.ParseIP(host) == nil && len(validation.NameIsDNSSubdomain(host, false)) != 0 {
+		errs = append(errs, field.Invalid(fldPath, address, "must have an IP address or a host name as host"))
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 0 || p > 65535 {
+		errs = append(errs, field.Invalid(fldPath, address, "must have a port between 0 and 65535"))
+	}
 	return errs
 }
@@ -0,0 +1,133 @@
+package options
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		mutate     func(o *Options)
+		wantFields []string
+	}{
+		{
+			name:   "defaults are valid",
+			mutate: func(o *Options) {},
+		},
+		{
+			name: "burst below QPS",
+			mutate: func(o *Options) {
+				o.KubeAPIQPS, o.KubeAPIBurst = 50, 10
+			},
+			wantFields: []string{"Options.KubeAPIBurst"},
+		},
+		{
+			name: "renew deadline over lease duration",
+			mutate: func(o *Options) {
+				o.LeaderElection.RenewDeadline.Duration = time.Minute
+			},
+			wantFields: []string{"Options.LeaderElection.LeaseDuration"},
+		},
+		{
+			name: "leader election is not checked when disabled",
+			mutate: func(o *Options) {
+				o.LeaderElection.LeaderElect = false
+				o.LeaderElection.RenewDeadline.Duration = time.Minute
+			},
+		},
+		{
+			name: "invalid bind addresses",
+			mutate: func(o *Options) {
+				o.MetricsAddr = "8080"
+				o.ProbeAddr = ":70000"
+			},
+			wantFields: []string{"Options.MetricsAddr", "Options.ProbeAddr"},
+		},
+		{
+			name: "disabled bind address",
+			mutate: func(o *Options) {
+				o.MetricsAddr = "0"
+			},
+		},
+		{
+			name: "unknown orphan sweep mode",
+			mutate: func(o *Options) {
+				o.OrphanSweepMode = "Purge"
+			},
+			wantFields: []string{"Options.OrphanSweepMode"},
+		},
+		{
+			name: "write burst required with a write limit",
+			mutate: func(o *Options) {
+				o.MaxWritesPerSecond = 5
+				o.WritesBurst = 0
+			},
+			wantFields: []string{"Options.WritesBurst"},
+		},
+		{
+			name: "invalid namespaces",
+			mutate: func(o *Options) {
+				o.WatchNamespaces = []string{"default", "Not_A_Namespace"}
+				o.NamespaceLabelSelector = "a in ("
+			},
+			wantFields: []string{"Options.NamespaceLabelSelector", "Options.WatchNamespaces[1]"},
+		},
+		{
+			name: "storage version migration without the conversion webhook",
+			mutate: func(o *Options) {
+				o.EnableWebhooks = false
+			},
+			wantFields: []string{"Options.MigrateStorageVersion"},
+		},
+		{
+			name: "webhooks disabled along the storage version migration",
+			mutate: func(o *Options) {
+				o.EnableWebhooks, o.MigrateStorageVersion = false, false
+				o.WebhookCertDir = ""
+			},
+		},
+		{
+			name: "webhook service",
+			mutate: func(o *Options) {
+				o.WebhookCertDir = ""
+				o.WebhookServiceName = "Puller_Webhook"
+			},
+			wantFields: []string{"Options.WebhookCertDir", "Options.WebhookServiceName"},
+		},
+		{
+			name: "tracing",
+			mutate: func(o *Options) {
+				o.TracingEndpoint = "collector"
+				o.TracingSamplingRatio = 2
+			},
+			wantFields: []string{"Options.TracingEndpoint", "Options.TracingSamplingRatio"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions()
+			fs := pflag.NewFlagSet("puller", pflag.ContinueOnError)
+			o.AddFlags(fs)
+			if err := fs.Parse(nil); err != nil {
+				t.Fatal(err)
+			}
+			tt.mutate(o)
+			errs := o.Validate()
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			if len(fields) != len(tt.wantFields) {
+				t.Fatalf("Validate() = %v, want errors on %v", errs, tt.wantFields)
+			}
+			for i := range fields {
+				if fields[i] != tt.wantFields[i] {
+					t.Errorf("Validate() error %d on %s, want %s", i, fields[i], tt.wantFields[i])
+				}
+			}
+		})
+	}
+}
@@ -4,23 +4,54 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/puller-io/puller/cmd/puller/app/options"
-	pullerv1alpha1 "github.com/puller-io/puller/pkg/apis/puller/v1alpha1"
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
 	"github.com/puller-io/puller/pkg/controller/puller"
+	"github.com/puller-io/puller/pkg/health"
+	"github.com/puller-io/puller/pkg/migration"
+	"github.com/puller-io/puller/pkg/registry"
 	"github.com/puller-io/puller/pkg/scheme"
+	"github.com/puller-io/puller/pkg/sharding"
+	"github.com/puller-io/puller/pkg/tracing"
 	"github.com/puller-io/puller/pkg/version"
+	"github.com/puller-io/puller/pkg/webhook/certs"
+	pullerwebhook "github.com/puller-io/puller/pkg/webhook/puller"
 )
 
+// apiServerProbePeriod is the interval between two round-trips of the
+// readiness probe to the API server.
+const apiServerProbePeriod = 10 * time.Second
+
+// tracingShutdownTimeout bounds the export of the remaining spans on exit.
+const tracingShutdownTimeout = 5 * time.Second
+
+// registryCheckTimeout bounds a request of the registry checks of a rollout.
+const registryCheckTimeout = 10 * time.Second
+
+// pullerCRD is the name of the CRD of the pullers.
+const pullerCRD = "pullers.puller.io"
+
 func NewControllerManagerCommand(ctx context.Context) *cobra.Command {
 	o := options.NewOptions()
 
@@ -28,6 +59,13 @@ func NewControllerManagerCommand(ctx context.Context) *cobra.Command {
 		Use:   "puller",
 		Short: "puller is a controller for pull private image",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.ConfigFile != "" {
+				cfg, err := options.LoadConfiguration(o.ConfigFile)
+				if err != nil {
+					return err
+				}
+				o.ApplyConfiguration(cfg)
+			}
 			if errs := o.Validate(); len(errs) != 0 {
 				return errs.ToAggregate()
 			}
@@ -66,11 +104,49 @@ func Run(ctx context.Context, opts *options.Options) error {
 		return err
 	}
 	config.QPS, config.Burst = opts.KubeAPIQPS, opts.KubeAPIBurst
+	kubeClient := kubernetes.NewForConfigOrDie(config)
+	dynamicClient := dynamic.NewForConfigOrDie(config)
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Endpoint:      opts.TracingEndpoint,
+		Insecure:      opts.TracingInsecure,
+		SamplingRatio: opts.TracingSamplingRatio,
+	})
+	if err != nil {
+		logger.Error(err, "failed to set up tracing")
+		return err
+	}
+	defer func() {
+		// flush the spans of the last reconciles
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			logger.Error(err, "failed to flush traces")
+		}
+	}()
+
+	namespaceSelector, err := labels.Parse(opts.NamespaceLabelSelector)
+	if err != nil {
+		return err
+	}
+	cacheOpts := cache.Options{
+		ByObject: map[client.Object]cache.ByObject{
+			// only the secrets distributed by puller are cached
+			&corev1.Secret{}: {Label: puller.ManagedSecretSelector()},
+		},
+		Namespaces: opts.WatchNamespaces,
+	}
+	if !namespaceSelector.Empty() {
+		cacheOpts.ByObject[&corev1.Namespace{}] = cache.ByObject{Label: namespaceSelector}
+	}
+
+	// the replicas share the pullers by shard instead of electing a leader
+	leaderElect := opts.LeaderElection.LeaderElect && opts.Shards == 0
 	mgr, err := ctrl.NewManager(config, ctrl.Options{
 		Logger:                     logger,
 		Scheme:                     scheme.Scheme,
 		SyncPeriod:                 &opts.ResyncPeriod.Duration,
-		LeaderElection:             opts.LeaderElection.LeaderElect,
+		LeaderElection:             leaderElect,
 		LeaderElectionID:           opts.LeaderElection.ResourceName,
 		LeaderElectionNamespace:    opts.LeaderElection.ResourceNamespace,
 		LeaseDuration:              &opts.LeaderElection.LeaseDuration.Duration,
@@ -82,9 +158,14 @@ func Run(ctx context.Context, opts *options.Options) error {
 		BaseContext: func() context.Context {
 			return ctx
 		},
+		Cache: cacheOpts,
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port:    opts.WebhookPort,
+			CertDir: opts.WebhookCertDir,
+		}),
 		Controller: ctrlconfig.Controller{
 			GroupKindConcurrency: map[string]int{
-				pullerv1alpha1.SchemeGroupVersion.WithKind("Puller").GroupKind().String(): opts.ConcurrentPullerSyncs,
+				pullerv1beta1.SchemeGroupVersion.WithKind("Puller").GroupKind().String(): opts.ConcurrentPullerSyncs,
 			},
 		},
 	})
@@ -93,25 +174,169 @@ func Run(ctx context.Context, opts *options.Options) error {
 		return err
 	}
 
-	if err = (&puller.Controller{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		KubeClient:    kubernetes.NewForConfigOrDie(mgr.GetConfig()),
-		EventRecorder: mgr.GetEventRecorderFor(puller.ControllerName),
-	}).SetupWithManager(mgr); err != nil {
+	// the limiter always exists, so that a reloaded config file can set a limit
+	writeLimiter := rate.NewLimiter(writeLimit(opts.MaxWritesPerSecond), opts.WritesBurst)
+	controller := &puller.Controller{
+		Client:                   mgr.GetClient(),
+		APIReader:                mgr.GetAPIReader(),
+		Scheme:                   mgr.GetScheme(),
+		KubeClient:               kubeClient,
+		EventRecorder:            mgr.GetEventRecorderFor(puller.ControllerName),
+		DryRun:                   opts.DryRun,
+		ResyncPeriod:             opts.ResyncPeriod.Duration,
+		ConcurrentNamespaceSyncs: opts.ConcurrentNamespaceSyncs,
+		FanOutWorkers:            opts.FanOutWorkers,
+		WriteLimiter:             writeLimiter,
+		WatchNamespaces:          opts.WatchNamespaces,
+		NamespaceSelector:        namespaceSelector,
+		RegistryChecker:          &registry.Checker{Client: &http.Client{Timeout: registryCheckTimeout}},
+		HistoryNamespace:         opts.HistoryNamespace,
+	}
+	if opts.Shards > 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		controller.Sharder = &sharding.Sharder{
+			Client:        kubeClient,
+			Namespace:     opts.LeaderElection.ResourceNamespace,
+			Group:         opts.LeaderElection.ResourceName,
+			Identity:      hostname + "_" + string(uuid.NewUUID()),
+			Shards:        opts.Shards,
+			LeaseDuration: opts.LeaderElection.LeaseDuration.Duration,
+			RetryPeriod:   opts.LeaderElection.RetryPeriod.Duration,
+		}
+		if err := mgr.Add(controller.Sharder); err != nil {
+			klog.Errorf("unable to set up sharder: %v", err)
+			return err
+		}
+	}
+	if err = controller.SetupWithManager(mgr); err != nil {
 		klog.Error(err, "unable to create controller", "controller", "Puller")
 		return fmt.Errorf("create puller controller failed, error: %v", err)
 	}
 
-	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
-		klog.Errorf("unable to set up health check: %w", err)
+	if opts.EnableWebhooks {
+		// the certificate is provisioned before the manager starts, so that
+		// the webhook server finds it
+		provisioner := &certs.Provisioner{
+			Client:      kubeClient,
+			Dynamic:     dynamicClient,
+			Namespace:   opts.WebhookServiceNamespace,
+			ServiceName: opts.WebhookServiceName,
+			SecretName:  opts.WebhookServiceName + "-cert",
+			CertDir:     opts.WebhookCertDir,
+			CRDs:        []string{pullerCRD},
+		}
+		if err := provisioner.Provision(ctx); err != nil {
+			klog.Errorf("unable to provision the webhook certificate: %v", err)
+			return err
+		}
+		if err := mgr.Add(provisioner); err != nil {
+			klog.Errorf("unable to set up webhook certificate provisioner: %v", err)
+			return err
+		}
+		// the conversion webhook is registered along, v1beta1 being the hub
+		if err := (&pullerwebhook.Webhook{Scheme: mgr.GetScheme()}).SetupWithManager(mgr); err != nil {
+			klog.Errorf("unable to set up puller webhook: %v", err)
+			return err
+		}
+	}
+
+	if opts.MigrateStorageVersion {
+		if err := mgr.Add(&migration.StorageVersionMigrator{
+			Dynamic:  dynamicClient,
+			CRD:      pullerCRD,
+			Resource: pullerv1beta1.SchemeGroupVersion.WithResource("pullers"),
+		}); err != nil {
+			klog.Errorf("unable to set up storage version migrator: %v", err)
+			return err
+		}
+	}
+
+	var sweeper *puller.OrphanSweeper
+	if opts.OrphanSweepPeriod.Duration > 0 {
+		sweeper = &puller.OrphanSweeper{
+			Controller: controller,
+			Period:     opts.OrphanSweepPeriod.Duration,
+			Delete:     opts.OrphanSweepMode == options.OrphanSweepModeDelete && !opts.DryRun,
+		}
+		if err := mgr.Add(sweeper); err != nil {
+			klog.Errorf("unable to set up orphan sweeper: %v", err)
+			return err
+		}
+	}
+
+	if opts.ConfigFile != "" {
+		if err := mgr.Add(&configReloader{
+			opts:         opts,
+			controller:   controller,
+			writeLimiter: writeLimiter,
+			sweeper:      sweeper,
+		}); err != nil {
+			klog.Errorf("unable to set up config reloader: %v", err)
+			return err
+		}
+	}
+
+	apiServerProbe := &health.APIServerProbe{
+		Client: kubeClient.Discovery(),
+		Period: apiServerProbePeriod,
+		MaxAge: 3 * apiServerProbePeriod,
+	}
+	if err := mgr.Add(apiServerProbe); err != nil {
+		klog.Errorf("unable to set up API server probe: %v", err)
 		return err
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
-		klog.Error("unable to set up ready check: %w", err)
+	healthChecks := map[string]healthz.Checker{
+		"ping": healthz.Ping,
+	}
+	if opts.WorkqueueStallTimeout.Duration > 0 {
+		healthChecks["workqueue"] = (&health.WorkqueueProgress{
+			Gatherer: metrics.Registry,
+			Stall:    opts.WorkqueueStallTimeout.Duration,
+			// a fan-out to many namespaces under the write rate limit
+			// outlasts the stall timeout, each namespace is progress
+			ProgressCounters: []string{puller.FanOutNamespacesMetric},
+		}).Check
+	}
+	readyChecks := map[string]healthz.Checker{
+		"cache-sync": health.CacheSynced(mgr.GetCache()),
+		"api-server": apiServerProbe.Check,
+	}
+	if opts.EnableWebhooks {
+		readyChecks["webhook"] = mgr.GetWebhookServer().StartedChecker()
+	}
+	for name, check := range healthChecks {
+		if err := mgr.AddHealthzCheck(name, check); err != nil {
+			klog.Errorf("unable to set up health check: %v", err)
+			return err
+		}
+	}
+	for name, check := range readyChecks {
+		if err := mgr.AddReadyzCheck(name, check); err != nil {
+			klog.Errorf("unable to set up ready check: %v", err)
+			return err
+		}
+	}
+	// the probe endpoints withhold the failure reasons, they are served
+	// on the metrics endpoint, e.g. /healthz?verbose
+	if err := mgr.AddMetricsExtraHandler("/healthz", &health.DetailHandler{Checks: healthChecks}); err != nil {
+		klog.Errorf("unable to set up health check detail: %v", err)
+		return err
+	}
+	if err := mgr.AddMetricsExtraHandler("/readyz", &health.DetailHandler{Checks: readyChecks}); err != nil {
+		klog.Errorf("unable to set up ready check detail: %v", err)
 		return err
 	}
 
+	if opts.EnableDebugEndpoint {
+		if err := mgr.AddMetricsExtraHandler("/debug/puller", controller.DebugHandler()); err != nil {
+			klog.Errorf("unable to set up debug endpoint: %v", err)
+			return err
+		}
+	}
+
 	// blocks until the context is done.
 	if err := mgr.Start(ctx); err != nil {
 		klog.Errorf("controller manager exits unexpectedly: %v", err)
@@ -23,6 +23,6 @@ CODEGEN_PKG=${CODEGEN_PKG:-$(cd "${SCRIPT_ROOT}"; ls -d -1 ./vendor/k8s.io/code-
 
 bash "${CODEGEN_PKG}"/generate-groups.sh "all" \
  github.com/puller-io/puller/pkg/generated github.com/puller-io/puller/pkg/apis \
- "puller:v1alpha1" \
+ "puller:v1alpha1,v1beta1" \
 --output-base "$(dirname "${BASH_SOURCE[0]}")/../../../../" \
 --go-header-file "${SCRIPT_ROOT}"/hack/boilerplate.go.txt
\ No newline at end of file
@@ -13,4 +13,8 @@ echo "Generating with controller-gen"
 util::install_tools ${CONTROLLER_GEN_PKG} ${CONTROLLER_GEN_VER} >/dev/null 2>&1
 
 # Unify the crds used by helm chart and the installation scripts
-controller-gen crd paths=./pkg/apis/puller/... output:crd:dir=./charts/puller/crds
\ No newline at end of file
+controller-gen crd paths=./pkg/apis/puller/... output:crd:dir=./charts/puller/crds
+# The pullers are converted between versions by the webhook of the
+# controller, which keeps the namespace and CA bundle of its service up to
+# date in the CRD.
+sed -i '/^spec:$/r hack/crd-conversion.yaml' ./charts/puller/crds/puller.io_pullers.yaml
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	componentbaseconfigv1alpha1 "k8s.io/component-base/config/v1alpha1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PullerControllerConfiguration configures the puller controller. Every
+// field is optional, the unset fields keep the value of their flag.
+type PullerControllerConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// LeaderElection configures the leader election, and the shard leases
+	// when sharding is enabled.
+	LeaderElection *componentbaseconfigv1alpha1.LeaderElectionConfiguration `json:"leaderElection,omitempty"`
+	// MetricsBindAddress is the address the metric endpoint binds to.
+	MetricsBindAddress *string `json:"metricsBindAddress,omitempty"`
+	// HealthProbeBindAddress is the address the probe endpoint binds to.
+	HealthProbeBindAddress *string `json:"healthProbeBindAddress,omitempty"`
+	// KubeAPIQPS is the QPS to use while talking with the API server.
+	KubeAPIQPS *float32 `json:"kubeAPIQPS,omitempty"`
+	// KubeAPIBurst is the burst to allow while talking with the API server.
+	KubeAPIBurst *int32 `json:"kubeAPIBurst,omitempty"`
+	// ResyncPeriod is the base frequency the informers are resynced.
+	ResyncPeriod *metav1.Duration `json:"resyncPeriod,omitempty"`
+	// ConcurrentPullerSyncs is the number of pullers that are allowed to
+	// sync concurrently.
+	ConcurrentPullerSyncs *int32 `json:"concurrentPullerSyncs,omitempty"`
+	// ConcurrentNamespaceSyncs is the number of puller and namespace pairs
+	// that are allowed to sync concurrently on namespace and secret events.
+	ConcurrentNamespaceSyncs *int32 `json:"concurrentNamespaceSyncs,omitempty"`
+	// FanOutWorkers is the number of namespaces a puller syncs in parallel.
+	// It is reloaded at runtime.
+	FanOutWorkers *int32 `json:"fanOutWorkers,omitempty"`
+	// MaxWritesPerSecond limits the writes to the target namespaces, zero
+	// means no limit. It is reloaded at runtime.
+	MaxWritesPerSecond *float64 `json:"maxWritesPerSecond,omitempty"`
+	// WritesBurst is the number of writes allowed at once over
+	// MaxWritesPerSecond. It is reloaded at runtime.
+	WritesBurst *int32 `json:"writesBurst,omitempty"`
+	// OrphanSweepPeriod is the interval between two sweeps of orphaned
+	// secrets and service account references. Zero disables the sweeper.
+	OrphanSweepPeriod *metav1.Duration `json:"orphanSweepPeriod,omitempty"`
+	// OrphanSweepMode is one of Report or Delete. It is reloaded at runtime.
+	OrphanSweepMode *string `json:"orphanSweepMode,omitempty"`
+	// WorkqueueStallTimeout is the time a workqueue with queued items may go
+	// without finishing any of them before the liveness check fails. Every
+	// namespace synced by a puller reconcile is progress. Zero disables the
+	// check.
+	WorkqueueStallTimeout *metav1.Duration `json:"workqueueStallTimeout,omitempty"`
+	// EnableDebugEndpoint serves the distribution state of the pullers on
+	// /debug/puller of the metrics endpoint.
+	EnableDebugEndpoint *bool `json:"enableDebugEndpoint,omitempty"`
+	// DryRun puts every puller in plan mode.
+	DryRun *bool `json:"dryRun,omitempty"`
+	// WatchNamespaces restricts the controller to these namespaces.
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+	// NamespaceLabelSelector restricts the controller to the namespaces it
+	// selects.
+	NamespaceLabelSelector *string `json:"namespaceLabelSelector,omitempty"`
+	// Shards is the number of shards the pullers are split into, zero
+	// disables sharding.
+	Shards *int32 `json:"shards,omitempty"`
+	// EnableWebhooks serves the conversion, defaulting and validating
+	// webhooks of the pullers, true by default as the Puller CRD converts
+	// its versions with the webhook.
+	EnableWebhooks *bool `json:"enableWebhooks,omitempty"`
+	// WebhookPort is the port the webhook server listens on.
+	WebhookPort *int32 `json:"webhookPort,omitempty"`
+	// WebhookCertDir holds the tls.crt and tls.key of the webhook server.
+	WebhookCertDir *string `json:"webhookCertDir,omitempty"`
+	// WebhookServiceName and WebhookServiceNamespace locate the service of
+	// the webhook server.
+	WebhookServiceName      *string `json:"webhookServiceName,omitempty"`
+	WebhookServiceNamespace *string `json:"webhookServiceNamespace,omitempty"`
+	// MigrateStorageVersion rewrites the pullers stored in a previous
+	// version in the storage version, it requires EnableWebhooks.
+	MigrateStorageVersion *bool `json:"migrateStorageVersion,omitempty"`
+	// HistoryNamespace holds the revisions of the credentials of the
+	// pullers, empty disables the history.
+	HistoryNamespace *string `json:"historyNamespace,omitempty"`
+	// TracingEndpoint is the host:port of the OTLP gRPC collector the traces
+	// are exported to, empty disables tracing.
+	TracingEndpoint *string `json:"tracingEndpoint,omitempty"`
+	// TracingInsecure disables TLS to the collector.
+	TracingInsecure *bool `json:"tracingInsecure,omitempty"`
+	// TracingSamplingRatio is the ratio of the reconciles that are traced.
+	TracingSamplingRatio *float64 `json:"tracingSamplingRatio,omitempty"`
+}
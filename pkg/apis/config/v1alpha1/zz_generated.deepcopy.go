@@ -0,0 +1,197 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by deepcopy-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	configv1alpha1 "k8s.io/component-base/config/v1alpha1"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PullerControllerConfiguration) DeepCopyInto(out *PullerControllerConfiguration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	if in.LeaderElection != nil {
+		in, out := &in.LeaderElection, &out.LeaderElection
+		*out = new(configv1alpha1.LeaderElectionConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MetricsBindAddress != nil {
+		in, out := &in.MetricsBindAddress, &out.MetricsBindAddress
+		*out = new(string)
+		**out = **in
+	}
+	if in.HealthProbeBindAddress != nil {
+		in, out := &in.HealthProbeBindAddress, &out.HealthProbeBindAddress
+		*out = new(string)
+		**out = **in
+	}
+	if in.KubeAPIQPS != nil {
+		in, out := &in.KubeAPIQPS, &out.KubeAPIQPS
+		*out = new(float32)
+		**out = **in
+	}
+	if in.KubeAPIBurst != nil {
+		in, out := &in.KubeAPIBurst, &out.KubeAPIBurst
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ResyncPeriod != nil {
+		in, out := &in.ResyncPeriod, &out.ResyncPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ConcurrentPullerSyncs != nil {
+		in, out := &in.ConcurrentPullerSyncs, &out.ConcurrentPullerSyncs
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ConcurrentNamespaceSyncs != nil {
+		in, out := &in.ConcurrentNamespaceSyncs, &out.ConcurrentNamespaceSyncs
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FanOutWorkers != nil {
+		in, out := &in.FanOutWorkers, &out.FanOutWorkers
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxWritesPerSecond != nil {
+		in, out := &in.MaxWritesPerSecond, &out.MaxWritesPerSecond
+		*out = new(float64)
+		**out = **in
+	}
+	if in.WritesBurst != nil {
+		in, out := &in.WritesBurst, &out.WritesBurst
+		*out = new(int32)
+		**out = **in
+	}
+	if in.OrphanSweepPeriod != nil {
+		in, out := &in.OrphanSweepPeriod, &out.OrphanSweepPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.OrphanSweepMode != nil {
+		in, out := &in.OrphanSweepMode, &out.OrphanSweepMode
+		*out = new(string)
+		**out = **in
+	}
+	if in.WorkqueueStallTimeout != nil {
+		in, out := &in.WorkqueueStallTimeout, &out.WorkqueueStallTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.EnableDebugEndpoint != nil {
+		in, out := &in.EnableDebugEndpoint, &out.EnableDebugEndpoint
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		*out = new(bool)
+		**out = **in
+	}
+	if in.WatchNamespaces != nil {
+		in, out := &in.WatchNamespaces, &out.WatchNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceLabelSelector != nil {
+		in, out := &in.NamespaceLabelSelector, &out.NamespaceLabelSelector
+		*out = new(string)
+		**out = **in
+	}
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = new(int32)
+		**out = **in
+	}
+	if in.EnableWebhooks != nil {
+		in, out := &in.EnableWebhooks, &out.EnableWebhooks
+		*out = new(bool)
+		**out = **in
+	}
+	if in.WebhookPort != nil {
+		in, out := &in.WebhookPort, &out.WebhookPort
+		*out = new(int32)
+		**out = **in
+	}
+	if in.WebhookCertDir != nil {
+		in, out := &in.WebhookCertDir, &out.WebhookCertDir
+		*out = new(string)
+		**out = **in
+	}
+	if in.WebhookServiceName != nil {
+		in, out := &in.WebhookServiceName, &out.WebhookServiceName
+		*out = new(string)
+		**out = **in
+	}
+	if in.WebhookServiceNamespace != nil {
+		in, out := &in.WebhookServiceNamespace, &out.WebhookServiceNamespace
+		*out = new(string)
+		**out = **in
+	}
+	if in.MigrateStorageVersion != nil {
+		in, out := &in.MigrateStorageVersion, &out.MigrateStorageVersion
+		*out = new(bool)
+		**out = **in
+	}
+	if in.HistoryNamespace != nil {
+		in, out := &in.HistoryNamespace, &out.HistoryNamespace
+		*out = new(string)
+		**out = **in
+	}
+	if in.TracingEndpoint != nil {
+		in, out := &in.TracingEndpoint, &out.TracingEndpoint
+		*out = new(string)
+		**out = **in
+	}
+	if in.TracingInsecure != nil {
+		in, out := &in.TracingInsecure, &out.TracingInsecure
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TracingSamplingRatio != nil {
+		in, out := &in.TracingSamplingRatio, &out.TracingSamplingRatio
+		*out = new(float64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PullerControllerConfiguration.
+func (in *PullerControllerConfiguration) DeepCopy() *PullerControllerConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(PullerControllerConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PullerControllerConfiguration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
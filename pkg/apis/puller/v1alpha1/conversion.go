@@ -0,0 +1,225 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/registry"
+)
+
+// ConversionDataAnnotationKey holds the fields of the v1beta1 spec that
+// v1alpha1 cannot represent, such as the credentials referenced from
+// Secrets, so that a v1alpha1 round-trip does not lose them.
+const ConversionDataAnnotationKey = "puller.io/conversion-data"
+
+var _ conversion.Convertible = &Puller{}
+
+// ConvertTo converts the puller to the hub version.
+func (src *Puller) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1beta1.Puller)
+	if !ok {
+		return fmt.Errorf("unsupported hub type %T", dstRaw)
+	}
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	// start from the fields saved by ConvertFrom, the fields of v1alpha1
+	// override them
+	saved := v1beta1.PullerSpec{}
+	if data, ok := dst.Annotations[ConversionDataAnnotationKey]; ok {
+		if err := json.Unmarshal([]byte(data), &saved); err != nil {
+			return fmt.Errorf("invalid %s annotation: %w", ConversionDataAnnotationKey, err)
+		}
+		delete(dst.Annotations, ConversionDataAnnotationKey)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+	}
+	dst.Spec = saved
+	convertSpecToHub(&src.Spec, &dst.Spec, saved.Registries)
+	convertStatusToHub(&src.Status, &dst.Status)
+	return nil
+}
+
+// ConvertFrom converts the puller from the hub version.
+func (dst *Puller) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1beta1.Puller)
+	if !ok {
+		return fmt.Errorf("unsupported hub type %T", srcRaw)
+	}
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	convertSpecFromHub(&src.Spec, &dst.Spec)
+	convertStatusFromHub(&src.Status, &dst.Status)
+
+	roundTrip := v1beta1.PullerSpec{}
+	convertSpecToHub(&dst.Spec, &roundTrip, nil)
+	if equality.Semantic.DeepEqual(&roundTrip, &src.Spec) {
+		return nil
+	}
+	// save what v1alpha1 cannot hold, without the inline credentials that
+	// it holds
+	saved := src.Spec.DeepCopy()
+	for i := range saved.Registries {
+		saved.Registries[i].Credentials.Basic = nil
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = map[string]string{}
+	}
+	dst.Annotations[ConversionDataAnnotationKey] = string(data)
+	return nil
+}
+
+// convertSpecToHub sets the fields of the hub spec v1alpha1 holds. The
+// validity of the saved registries is kept for the registries of the same
+// server, however spelled, and their credentials for the ones without
+// inline credentials.
+func convertSpecToHub(src *PullerSpec, dst *v1beta1.PullerSpec, saved []v1beta1.Registry) {
+	dst.Registries = nil
+	for _, r := range src.Registries {
+		reg := v1beta1.Registry{Server: r.Server}
+		inline := r.Username != "" || r.Password != "" || r.Auth != "" || r.Email != ""
+		if inline {
+			reg.Credentials.Basic = &v1beta1.BasicCredentials{
+				Username: r.Username,
+				Password: r.Password,
+				Auth:     r.Auth,
+				Email:    r.Email,
+			}
+		}
+		for _, s := range saved {
+			// the hub servers are normalized by the defaulter, the v1alpha1
+			// clients may spell them otherwise
+			if registry.ConfigKey(s.Server) != registry.ConfigKey(r.Server) {
+				continue
+			}
+			if !inline {
+				reg.Credentials = *s.Credentials.DeepCopy()
+			}
+			reg.ValidFrom = s.ValidFrom.DeepCopy()
+			reg.ExpiresAt = s.ExpiresAt.DeepCopy()
+			break
+		}
+		dst.Registries = append(dst.Registries, reg)
+	}
+	dst.NamespaceSelector = src.NamespaceAffinity.DeepCopy()
+	dst.SecretTemplate = nil
+	if t := src.SecretTemplate; t != nil {
+		dst.SecretTemplate = &v1beta1.SecretTemplate{
+			Name:        t.Name,
+			Labels:      t.Labels,
+			Annotations: t.Annotations,
+			Type:        t.Type,
+		}
+	}
+	dst.ConflictPolicy = v1beta1.ConflictPolicy(src.ConflictPolicy)
+	dst.DeletionPolicy = v1beta1.DeletionPolicy(src.DeletionPolicy)
+	dst.Suspend = src.Suspend
+	dst.Mode = v1beta1.Mode(src.Mode)
+}
+
+func convertSpecFromHub(src *v1beta1.PullerSpec, dst *PullerSpec) {
+	dst.Registries = nil
+	for _, r := range src.Registries {
+		reg := Registry{Server: r.Server}
+		if b := r.Credentials.Basic; b != nil {
+			reg.Username = b.Username
+			reg.Password = b.Password
+			reg.Auth = b.Auth
+			reg.Email = b.Email
+		}
+		dst.Registries = append(dst.Registries, reg)
+	}
+	dst.NamespaceAffinity = src.NamespaceSelector.DeepCopy()
+	dst.SecretTemplate = nil
+	if t := src.SecretTemplate; t != nil {
+		dst.SecretTemplate = &SecretTemplate{
+			Name:        t.Name,
+			Labels:      t.Labels,
+			Annotations: t.Annotations,
+			Type:        t.Type,
+		}
+	}
+	dst.ConflictPolicy = ConflictPolicy(src.ConflictPolicy)
+	dst.DeletionPolicy = DeletionPolicy(src.DeletionPolicy)
+	dst.Suspend = src.Suspend
+	dst.Mode = Mode(src.Mode)
+}
+
+// convertStatusToHub converts the status. The status is owned by the
+// controller, which writes it in the hub version: the fields only the hub
+// has, such as the revision and the rollout, are not saved by ConvertFrom
+// and a status written in v1alpha1 leaves them to the next sync to fill.
+func convertStatusToHub(src *PullerStatus, dst *v1beta1.PullerStatus) {
+	dst.Conditions = src.Conditions
+	dst.Plan = nil
+	if p := src.Plan; p != nil {
+		dst.Plan = &v1beta1.Plan{ObservedGeneration: p.ObservedGeneration, Total: p.Total}
+		for _, c := range p.Changes {
+			dst.Plan.Changes = append(dst.Plan.Changes, v1beta1.PlannedChange{
+				Action:    v1beta1.PlanAction(c.Action),
+				Kind:      c.Kind,
+				Namespace: c.Namespace,
+				Name:      c.Name,
+			})
+		}
+	}
+	dst.Progress = nil
+	if p := src.Progress; p != nil {
+		dst.Progress = &v1beta1.Progress{
+			ObservedGeneration: p.ObservedGeneration,
+			Namespace:          p.Namespace,
+			Synced:             p.Synced,
+			Total:              p.Total,
+			Blocked:            p.Blocked,
+		}
+	}
+}
+
+func convertStatusFromHub(src *v1beta1.PullerStatus, dst *PullerStatus) {
+	dst.Conditions = src.Conditions
+	dst.Plan = nil
+	if p := src.Plan; p != nil {
+		dst.Plan = &Plan{ObservedGeneration: p.ObservedGeneration, Total: p.Total}
+		for _, c := range p.Changes {
+			dst.Plan.Changes = append(dst.Plan.Changes, PlannedChange{
+				Action:    PlanAction(c.Action),
+				Kind:      c.Kind,
+				Namespace: c.Namespace,
+				Name:      c.Name,
+			})
+		}
+	}
+	dst.Progress = nil
+	if p := src.Progress; p != nil {
+		dst.Progress = &Progress{
+			ObservedGeneration: p.ObservedGeneration,
+			Namespace:          p.Namespace,
+			Synced:             p.Synced,
+			Total:              p.Total,
+			Blocked:            p.Blocked,
+		}
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+func TestConvertRoundTrip(t *testing.T) {
+	expiresAt := metav1.NewTime(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	rollbackTo := int64(2)
+	tests := []struct {
+		name           string
+		spec           v1beta1.PullerSpec
+		wantAnnotation bool
+	}{
+		{
+			name: "inline credentials",
+			spec: v1beta1.PullerSpec{
+				Registries: []v1beta1.Registry{{
+					Server: "harbor.corp",
+					Credentials: v1beta1.RegistryCredentials{
+						Basic: &v1beta1.BasicCredentials{Username: "user", Password: "secret"},
+					},
+				}},
+				ConflictPolicy: v1beta1.ConflictPolicyAdopt,
+				Suspend:        true,
+			},
+		},
+		{
+			name: "secret reference of a non-canonical server",
+			spec: v1beta1.PullerSpec{
+				Registries: []v1beta1.Registry{
+					{
+						Server: "https://Harbor.Corp/team-a/",
+						Credentials: v1beta1.RegistryCredentials{
+							SecretRef: &v1beta1.SecretReference{Namespace: "puller", Name: "harbor"},
+						},
+						ExpiresAt: &expiresAt,
+					},
+					{
+						Server: "docker.io",
+						Credentials: v1beta1.RegistryCredentials{
+							Basic: &v1beta1.BasicCredentials{Username: "user", Password: "secret"},
+						},
+					},
+				},
+			},
+			wantAnnotation: true,
+		},
+		{
+			name: "token credentials and v1beta1 fields",
+			spec: v1beta1.PullerSpec{
+				Registries: []v1beta1.Registry{{
+					Server: "*.azurecr.io",
+					Credentials: v1beta1.RegistryCredentials{
+						Token: &v1beta1.TokenCredentials{IdentityToken: "token"},
+					},
+				}},
+				Outputs:    []v1beta1.Output{{Format: v1beta1.OutputFormatDockerCfg}},
+				Rotation:   &v1beta1.Rotation{Strategy: v1beta1.RotationStrategyImmutable},
+				RollbackTo: &rollbackTo,
+			},
+			wantAnnotation: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := &v1beta1.Puller{
+				ObjectMeta: metav1.ObjectMeta{Name: "puller", Labels: map[string]string{"team": "a"}},
+				Spec:       tt.spec,
+			}
+			spoke := &Puller{}
+			if err := spoke.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom() error = %v", err)
+			}
+			if _, ok := spoke.Annotations[ConversionDataAnnotationKey]; ok != tt.wantAnnotation {
+				t.Errorf("ConvertFrom() annotations = %v, want conversion data %v", spoke.Annotations, tt.wantAnnotation)
+			}
+			got := &v1beta1.Puller{}
+			if err := spoke.ConvertTo(got); err != nil {
+				t.Fatalf("ConvertTo() error = %v", err)
+			}
+			if !equality.Semantic.DeepEqual(got, hub) {
+				t.Errorf("round-trip = %+v, want %+v", got, hub)
+			}
+		})
+	}
+}
+
+func TestConvertToRespelledServer(t *testing.T) {
+	hub := &v1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller"},
+		Spec: v1beta1.PullerSpec{
+			Registries: []v1beta1.Registry{{
+				Server: "harbor.corp",
+				Credentials: v1beta1.RegistryCredentials{
+					SecretRef: &v1beta1.SecretReference{Namespace: "puller", Name: "harbor"},
+				},
+			}},
+		},
+	}
+	spoke := &Puller{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom() error = %v", err)
+	}
+	// a v1alpha1 client writes the server back in another spelling
+	spoke.Spec.Registries[0].Server = "https://HARBOR.corp/"
+	got := &v1beta1.Puller{}
+	if err := spoke.ConvertTo(got); err != nil {
+		t.Fatalf("ConvertTo() error = %v", err)
+	}
+	if ref := got.Spec.Registries[0].Credentials.SecretRef; ref == nil || *ref != *hub.Spec.Registries[0].Credentials.SecretRef {
+		t.Errorf("ConvertTo() credentials = %+v, want the secret reference kept", got.Spec.Registries[0].Credentials)
+	}
+}
+
+func TestConvertStatus(t *testing.T) {
+	hub := &v1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller"},
+		Status: v1beta1.PullerStatus{
+			ObservedGeneration: 3,
+			Revision:           2,
+			TargetNamespaces:   5,
+			Conditions: []metav1.Condition{{
+				Type:   "Ready",
+				Status: metav1.ConditionTrue,
+				Reason: "Ready",
+			}},
+			Progress: &v1beta1.Progress{ObservedGeneration: 3, Namespace: "b", Synced: 2, Total: 5},
+			Rollout:  &v1beta1.RolloutStatus{Revision: "abc", Phase: v1beta1.RolloutPhaseComplete},
+		},
+	}
+	spoke := &Puller{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom() error = %v", err)
+	}
+	got := &v1beta1.Puller{}
+	if err := spoke.ConvertTo(got); err != nil {
+		t.Fatalf("ConvertTo() error = %v", err)
+	}
+	// the status is owned by the controller, which writes it in v1beta1, the
+	// fields only the hub has are not saved
+	want := v1beta1.PullerStatus{Conditions: hub.Status.Conditions, Progress: hub.Status.Progress}
+	if !equality.Semantic.DeepEqual(got.Status, want) {
+		t.Errorf("round-trip status = %+v, want %+v", got.Status, want)
+	}
+}
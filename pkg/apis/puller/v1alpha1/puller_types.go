@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -10,6 +11,7 @@ import (
 // +kubebuilder:subresource:status
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 //+kubebuilder:printcolumn:name="Ready",type=string,description="Report the puller ready status",JSONPath=`.status.conditions[?(@.type=="Ready")].status`,priority=0
+//+kubebuilder:printcolumn:name="Suspend",type=boolean,description="Report whether the puller is suspended",JSONPath=`.spec.suspend`,priority=0
 //+kubebuilder:printcolumn:name="Age",type=date,description="The creation date",JSONPath=`.metadata.creationTimestamp`,priority=0
 
 // Puller is the Schema for the fast api
@@ -37,8 +39,78 @@ type PullerSpec struct {
 
 	// +kubebuilder:validation:Optional
 	NamespaceAffinity *metav1.LabelSelector `json:"namespaceAffinity,omitempty"`
+
+	// SecretTemplate customizes the Secret distributed to each namespace.
+	// +kubebuilder:validation:Optional
+	SecretTemplate *SecretTemplate `json:"secretTemplate,omitempty"`
+
+	// ConflictPolicy decides what happens when a Secret with the same name
+	// already exists in a namespace and is not managed by this puller. The
+	// Secrets of another puller are never taken over. Defaults to Fail.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Fail;Adopt;Overwrite
+	ConflictPolicy ConflictPolicy `json:"conflictPolicy,omitempty"`
+
+	// DeletionPolicy decides what happens to the distributed Secrets and
+	// ServiceAccount references when the puller is deleted. Defaults to Delete.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Delete;Retain
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Suspend pauses the distribution of the puller without deleting it.
+	// Nothing is written to the target namespaces while it is set.
+	// +kubebuilder:validation:Optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Mode decides whether the controller applies the changes or only
+	// publishes them as a plan in the status. Defaults to Apply.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Apply;Plan
+	Mode Mode `json:"mode,omitempty"`
+}
+
+// Mode defines how the controller handles the changes of a puller.
+type Mode string
+
+const (
+	// ModeApply applies the changes to the target namespaces.
+	ModeApply Mode = "Apply"
+	// ModePlan computes the changes and publishes them in the status without writing.
+	ModePlan Mode = "Plan"
+)
+
+// SecretTemplate describes the metadata and type of the distributed Secret.
+type SecretTemplate struct {
+	// Name of the Secret. Defaults to the name of the puller.
+	// +kubebuilder:validation:Optional
+	Name string `json:"name,omitempty"`
+
+	// Labels are merged into the labels of the Secret.
+	// +kubebuilder:validation:Optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are merged into the annotations of the Secret.
+	// +kubebuilder:validation:Optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Type of the Secret. Defaults to kubernetes.io/dockerconfigjson.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=kubernetes.io/dockerconfigjson;Opaque
+	Type corev1.SecretType `json:"type,omitempty"`
 }
 
+// ConflictPolicy defines how to handle a Secret that is not managed by the puller.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyFail leaves the existing Secret untouched and reports the conflict.
+	ConflictPolicyFail ConflictPolicy = "Fail"
+	// ConflictPolicyAdopt takes ownership of the existing Secret, keeping its metadata.
+	ConflictPolicyAdopt ConflictPolicy = "Adopt"
+	// ConflictPolicyOverwrite replaces the existing Secret.
+	ConflictPolicyOverwrite ConflictPolicy = "Overwrite"
+)
+
 type Registry struct {
 	// +kubebuilder:validation:Optional
 	Server string `json:"server,omitempty"`
@@ -56,8 +128,91 @@ type Registry struct {
 	Auth string `json:"auth,omitempty"`
 }
 
-// PullerStatus defines the observed state of Puller
+// DeletionPolicy defines how to handle the distributed objects on puller deletion.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the managed Secrets and removes the
+	// ServiceAccount references added by the puller.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain releases the managed Secrets and keeps the
+	// ServiceAccount references in place.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// PullerStatus defines the observed state of Puller. The observed
+// generation, revision, registries, rollout and target namespaces are only
+// served by v1beta1.
 type PullerStatus struct {
 	// +kubebuilder:validation:Optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Plan is the set of changes computed while the puller is in plan mode.
+	// +kubebuilder:validation:Optional
+	Plan *Plan `json:"plan,omitempty"`
+
+	// Progress is the checkpoint of a distribution in progress, a restarted
+	// controller resumes from it instead of starting over.
+	// +kubebuilder:validation:Optional
+	Progress *Progress `json:"progress,omitempty"`
+}
+
+// Progress records how far the distribution of a puller went.
+type Progress struct {
+	// ObservedGeneration is the generation of the puller being distributed.
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Namespace is the last target namespace, in lexical order, up to which
+	// every namespace is synced.
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Synced is the number of target namespaces synced so far.
+	// +kubebuilder:validation:Optional
+	Synced int `json:"synced,omitempty"`
+
+	// Total is the number of target namespaces.
+	// +kubebuilder:validation:Optional
+	Total int `json:"total,omitempty"`
+
+	// Blocked lists the synced namespaces where an unmanaged secret blocks
+	// the distribution.
+	// +kubebuilder:validation:Optional
+	Blocked []string `json:"blocked,omitempty"`
+}
+
+// Plan describes the changes the controller would make to distribute a puller.
+type Plan struct {
+	// ObservedGeneration is the generation of the puller the plan was computed for.
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Total is the number of planned changes, which may be larger than the
+	// number of listed changes.
+	// +kubebuilder:validation:Optional
+	Total int `json:"total,omitempty"`
+
+	// Changes lists the planned changes.
+	// +kubebuilder:validation:Optional
+	Changes []PlannedChange `json:"changes,omitempty"`
+}
+
+// PlannedChange is a single write the controller would make.
+type PlannedChange struct {
+	Action PlanAction `json:"action"`
+	Kind   string     `json:"kind"`
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
 }
+
+// PlanAction is the kind of write of a planned change.
+type PlanAction string
+
+const (
+	PlanActionCreate PlanAction = "Create"
+	PlanActionUpdate PlanAction = "Update"
+	PlanActionDelete PlanAction = "Delete"
+	PlanActionPatch  PlanAction = "Patch"
+)
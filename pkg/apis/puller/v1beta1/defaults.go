@@ -0,0 +1,38 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/puller-io/puller/pkg/registry"
+)
+
+// SetDefaults_Puller sets the defaults of the policies and the canonical
+// form of the registry servers.
+func SetDefaults_Puller(obj *Puller) {
+	if obj.Spec.ConflictPolicy == "" {
+		obj.Spec.ConflictPolicy = ConflictPolicyFail
+	}
+	if obj.Spec.DeletionPolicy == "" {
+		obj.Spec.DeletionPolicy = DeletionPolicyDelete
+	}
+	if obj.Spec.Mode == "" {
+		obj.Spec.Mode = ModeApply
+	}
+	for i := range obj.Spec.Registries {
+		obj.Spec.Registries[i].Server = registry.NormalizeServer(obj.Spec.Registries[i].Server)
+	}
+}
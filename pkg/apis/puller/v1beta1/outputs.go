@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import "time"
+
+// outputSuffixes are appended to the name of the Secret template to name
+// the outputs without a name.
+var outputSuffixes = map[OutputFormat]string{
+	OutputFormatDockerCfg:          "-dockercfg",
+	OutputFormatConfigJSON:         "-config",
+	OutputFormatFluxHelmRepository: "-flux",
+	OutputFormatArgoCDRepository:   "-argocd",
+}
+
+// SecretName returns the name of the Secret template of the puller.
+func SecretName(puller *Puller) string {
+	if t := puller.Spec.SecretTemplate; t != nil && len(t.Name) != 0 {
+		return t.Name
+	}
+	return puller.Name
+}
+
+// OutputsOf returns the outputs of the puller with their names set, a
+// single DockerConfigJSON output when the spec has none.
+func OutputsOf(puller *Puller) []Output {
+	outputs := puller.Spec.Outputs
+	if len(outputs) == 0 {
+		outputs = []Output{{Format: OutputFormatDockerConfigJSON}}
+	}
+	named := make([]Output, len(outputs))
+	for i, output := range outputs {
+		named[i] = output
+		if named[i].Name == "" {
+			named[i].Name = SecretName(puller) + outputSuffixes[output.Format]
+		}
+	}
+	return named
+}
+
+// IsPullSecret reports whether the ServiceAccounts reference the Secrets of
+// the format as image pull secrets.
+func (f OutputFormat) IsPullSecret() bool {
+	return f == OutputFormatDockerConfigJSON || f == OutputFormatDockerCfg
+}
+
+// VersionHashLength is the length of the content hash suffixed to the name
+// of an immutable image pull secret.
+const VersionHashLength = 10
+
+// VersionedName returns the name of the version of an immutable image pull
+// secret with the content hash.
+func VersionedName(name, hash string) string {
+	if len(hash) > VersionHashLength {
+		hash = hash[:VersionHashLength]
+	}
+	return name + "-" + hash
+}
+
+// DefaultRotationGracePeriod is the grace period of the previous versions
+// of the immutable image pull secrets.
+const DefaultRotationGracePeriod = time.Hour
+
+// ImmutableSecrets reports whether the puller rotates its image pull
+// secrets as immutable versions.
+func ImmutableSecrets(puller *Puller) bool {
+	r := puller.Spec.Rotation
+	return r != nil && r.Strategy == RotationStrategyImmutable
+}
+
+// RotationGracePeriod returns the grace period of the previous versions of
+// the image pull secrets of the puller.
+func RotationGracePeriod(puller *Puller) time.Duration {
+	if r := puller.Spec.Rotation; r != nil && r.GracePeriod != nil {
+		return r.GracePeriod.Duration
+	}
+	return DefaultRotationGracePeriod
+}
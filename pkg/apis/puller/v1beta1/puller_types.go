@@ -0,0 +1,557 @@
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:resource:scope="Cluster",singular="puller",path="pullers"
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+//+kubebuilder:printcolumn:name="Ready",type=string,description="Report the puller ready status",JSONPath=`.status.conditions[?(@.type=="Ready")].status`,priority=0
+//+kubebuilder:printcolumn:name="Suspend",type=boolean,description="Report whether the puller is suspended",JSONPath=`.spec.suspend`,priority=0
+//+kubebuilder:printcolumn:name="Namespaces",type=integer,description="The number of target namespaces",JSONPath=`.status.targetNamespaces`,priority=1
+//+kubebuilder:printcolumn:name="Age",type=date,description="The creation date",JSONPath=`.metadata.creationTimestamp`,priority=0
+
+// Puller distributes image pull secrets to the namespaces it selects.
+type Puller struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PullerSpec   `json:"spec,omitempty"`
+	Status PullerStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PullerList contains a list of Puller
+type PullerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Puller `json:"items"`
+}
+
+// PullerSpec defines the desired state of Puller
+type PullerSpec struct {
+	// Registries lists the registries and their credentials.
+	// +kubebuilder:validation:Optional
+	Registries []Registry `json:"registries,omitempty"`
+
+	// NamespaceSelector selects the namespaces the Secret is distributed to.
+	// Defaults to every namespace.
+	// +kubebuilder:validation:Optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// SecretTemplate customizes the Secret distributed to each namespace.
+	// +kubebuilder:validation:Optional
+	SecretTemplate *SecretTemplate `json:"secretTemplate,omitempty"`
+
+	// Outputs lists the Secrets generated from the registries in each
+	// namespace. Defaults to a single DockerConfigJSON Secret.
+	// +kubebuilder:validation:Optional
+	Outputs []Output `json:"outputs,omitempty"`
+
+	// Rotation decides how the image pull secrets are updated when their
+	// content changes. Defaults to updating them in place.
+	// +kubebuilder:validation:Optional
+	Rotation *Rotation `json:"rotation,omitempty"`
+
+	// RolloutStrategy applies the changes of the Secrets in stages, the
+	// canary namespaces first, instead of to every namespace at once.
+	// +kubebuilder:validation:Optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// RevisionHistoryLimit is the number of revisions of the credentials
+	// kept by the controller, besides the distributed one. Defaults to 10.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// RollbackTo distributes the credentials of a previous revision in place
+	// of the ones of the registries, while it is set. The other fields of
+	// the spec still apply.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	RollbackTo *int64 `json:"rollbackTo,omitempty"`
+
+	// ExpiryWarnings lists the remaining validities of the credentials of a
+	// registry at which a warning is raised, an ExpiringSoon condition is
+	// set from the longest one on. Defaults to [168h, 24h].
+	// +kubebuilder:validation:Optional
+	ExpiryWarnings []metav1.Duration `json:"expiryWarnings,omitempty"`
+
+	// ConflictPolicy decides what happens when a Secret with the same name
+	// already exists in a namespace and is not managed by this puller. The
+	// Secrets of another puller are never taken over. Defaults to Fail.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Fail;Adopt;Overwrite
+	ConflictPolicy ConflictPolicy `json:"conflictPolicy,omitempty"`
+
+	// DeletionPolicy decides what happens to the distributed Secrets and
+	// ServiceAccount references when the puller is deleted. Defaults to Delete.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Delete;Retain
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Suspend pauses the distribution of the puller without deleting it.
+	// Nothing is written to the target namespaces while it is set.
+	// +kubebuilder:validation:Optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Mode decides whether the controller applies the changes or only
+	// publishes them as a plan in the status. Defaults to Apply.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Apply;Plan
+	Mode Mode `json:"mode,omitempty"`
+}
+
+// Mode defines how the controller handles the changes of a puller.
+type Mode string
+
+const (
+	// ModeApply applies the changes to the target namespaces.
+	ModeApply Mode = "Apply"
+	// ModePlan computes the changes and publishes them in the status without writing.
+	ModePlan Mode = "Plan"
+)
+
+// SecretTemplate describes the metadata and type of the distributed Secret.
+// The labels and annotations apply to every output, the type only to the
+// DockerConfigJSON outputs.
+type SecretTemplate struct {
+	// Name of the Secret. Defaults to the name of the puller.
+	// +kubebuilder:validation:Optional
+	Name string `json:"name,omitempty"`
+
+	// Labels are merged into the labels of the Secret.
+	// +kubebuilder:validation:Optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are merged into the annotations of the Secret.
+	// +kubebuilder:validation:Optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Type of the Secret. Defaults to kubernetes.io/dockerconfigjson.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=kubernetes.io/dockerconfigjson;Opaque
+	Type corev1.SecretType `json:"type,omitempty"`
+}
+
+// Output is a Secret generated from the registries.
+type Output struct {
+	// Format of the Secret.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=DockerConfigJSON;DockerCfg;ConfigJSON;FluxHelmRepository;ArgoCDRepository
+	Format OutputFormat `json:"format"`
+
+	// Name of the Secret. Defaults to the name of the Secret template for
+	// DockerConfigJSON, and to it suffixed with the format otherwise, e.g.
+	// <name>-dockercfg.
+	// +kubebuilder:validation:Optional
+	Name string `json:"name,omitempty"`
+
+	// Server selects the registry whose credentials the FluxHelmRepository
+	// and ArgoCDRepository Secrets hold, they only hold one.
+	// +kubebuilder:validation:Optional
+	Server string `json:"server,omitempty"`
+}
+
+// Rotation describes how the image pull secrets are rotated.
+type Rotation struct {
+	// Strategy of the rotation. Defaults to InPlace.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=InPlace;Immutable
+	Strategy RotationStrategy `json:"strategy,omitempty"`
+
+	// GracePeriod is how long a previous version of an image pull secret
+	// is kept once the ServiceAccounts reference the new one, for the pods
+	// that still reference it. Defaults to 1h.
+	// +kubebuilder:validation:Optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// RotationStrategy defines how the image pull secrets are rotated.
+type RotationStrategy string
+
+const (
+	// RotationStrategyInPlace updates the image pull secrets in place.
+	RotationStrategyInPlace RotationStrategy = "InPlace"
+	// RotationStrategyImmutable creates an immutable Secret named after its
+	// content, <name>-<hash>, for each version of an image pull secret. The
+	// ServiceAccounts are switched to the new version and the previous one
+	// is deleted after the grace period. The outputs that are not image
+	// pull secrets are still updated in place, their consumers reference
+	// them by name.
+	RotationStrategyImmutable RotationStrategy = "Immutable"
+)
+
+// RolloutStrategy describes a staged rollout. The changes are applied to
+// the canary namespaces, soak there, and are then applied to the other
+// target namespaces by batches. The credentials are checked against the
+// registries before each batch, a rejection stops the rollout and the
+// namespaces it has not reached keep their previous Secrets.
+type RolloutStrategy struct {
+	// Canary selects the target namespaces the changes are applied to first.
+	// +kubebuilder:validation:Required
+	Canary *metav1.LabelSelector `json:"canary"`
+
+	// SoakDuration is how long the changes soak in the canary namespaces
+	// before the first batch. Defaults to 10m.
+	// +kubebuilder:validation:Optional
+	SoakDuration *metav1.Duration `json:"soakDuration,omitempty"`
+
+	// Batches lists the cumulative percentages of the other target
+	// namespaces updated by each batch, in increasing order and ending with
+	// 100. Defaults to [100].
+	// +kubebuilder:validation:Optional
+	Batches []int32 `json:"batches,omitempty"`
+
+	// BatchInterval is the time between two batches. Defaults to 5m.
+	// +kubebuilder:validation:Optional
+	BatchInterval *metav1.Duration `json:"batchInterval,omitempty"`
+}
+
+// OutputFormat is the format of a generated Secret.
+type OutputFormat string
+
+const (
+	// OutputFormatDockerConfigJSON is a kubernetes.io/dockerconfigjson
+	// Secret, referenced by the ServiceAccounts.
+	OutputFormatDockerConfigJSON OutputFormat = "DockerConfigJSON"
+	// OutputFormatDockerCfg is a legacy kubernetes.io/dockercfg Secret,
+	// referenced by the ServiceAccounts.
+	OutputFormatDockerCfg OutputFormat = "DockerCfg"
+	// OutputFormatConfigJSON is an Opaque Secret holding the docker config
+	// in the config.json key, to be mounted by the build tools.
+	OutputFormatConfigJSON OutputFormat = "ConfigJSON"
+	// OutputFormatFluxHelmRepository is an Opaque Secret holding the
+	// username and password keys of a registry, referenced by the Flux
+	// HelmRepositories of type oci. The Flux OCIRepositories reference a
+	// DockerConfigJSON Secret.
+	OutputFormatFluxHelmRepository OutputFormat = "FluxHelmRepository"
+	// OutputFormatArgoCDRepository is an Argo CD repository credentials
+	// Secret for the OCI Helm charts of a registry.
+	OutputFormatArgoCDRepository OutputFormat = "ArgoCDRepository"
+)
+
+// ConflictPolicy defines how to handle a Secret that is not managed by the puller.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyFail leaves the existing Secret untouched and reports the conflict.
+	ConflictPolicyFail ConflictPolicy = "Fail"
+	// ConflictPolicyAdopt takes ownership of the existing Secret, keeping its metadata.
+	ConflictPolicyAdopt ConflictPolicy = "Adopt"
+	// ConflictPolicyOverwrite replaces the existing Secret.
+	ConflictPolicyOverwrite ConflictPolicy = "Overwrite"
+)
+
+// Registry is a registry server and the credentials to pull from it.
+type Registry struct {
+	// Server is the host of the registry, with an optional port and an
+	// optional repository path. The leftmost labels of the host may be
+	// wildcards, e.g. *.azurecr.io, and a path scopes the credentials to the
+	// repositories under it, e.g. harbor.corp/team-a. The most specific
+	// server matching an image is used.
+	// +kubebuilder:validation:Required
+	Server string `json:"server"`
+
+	// Credentials of the registry, from at most one source. A registry
+	// without credentials, which v1alpha1 accepted, is not distributed and
+	// is reported by the CredentialsMissing condition.
+	// +kubebuilder:validation:Required
+	Credentials RegistryCredentials `json:"credentials"`
+
+	// ValidFrom is when the credentials become valid, they are not
+	// distributed before. Defaults to the puller.io/valid-from annotation of
+	// the referenced Secret, or to the nbf claim of a JWT credential.
+	// +kubebuilder:validation:Optional
+	ValidFrom *metav1.Time `json:"validFrom,omitempty"`
+
+	// ExpiresAt is when the credentials expire, they are not distributed
+	// after: the docker configs drop the registry and the outputs of the
+	// registry alone blank its username and password. Defaults to the
+	// puller.io/expires-at annotation of the referenced Secret, or to the
+	// exp claim of a JWT credential.
+	// +kubebuilder:validation:Optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+// RegistryCredentials are the sources of the credentials of a registry.
+type RegistryCredentials struct {
+	// Basic holds the credentials inline.
+	// +kubebuilder:validation:Optional
+	Basic *BasicCredentials `json:"basic,omitempty"`
+
+	// Token holds the tokens of a registry authenticating with OAuth inline.
+	// +kubebuilder:validation:Optional
+	Token *TokenCredentials `json:"token,omitempty"`
+
+	// SecretRef references a Secret holding either the username and password
+	// keys, as a kubernetes.io/basic-auth Secret, or the identitytoken key,
+	// with an optional username key, or the registrytoken key. Its changes
+	// are distributed at the next resync of the puller. The provider of the
+	// credentials may expose their validity window in the
+	// puller.io/valid-from and puller.io/expires-at annotations of the
+	// Secret, in RFC 3339, which validFrom and expiresAt override.
+	// +kubebuilder:validation:Optional
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+}
+
+const (
+	// IdentityTokenKey is the key of the identity token in a referenced Secret.
+	IdentityTokenKey = "identitytoken"
+	// RegistryTokenKey is the key of the registry token in a referenced Secret.
+	RegistryTokenKey = "registrytoken"
+	// ValidFromAnnotationKey exposes when the credentials of a referenced
+	// Secret become valid.
+	ValidFromAnnotationKey = "puller.io/valid-from"
+	// ExpiresAtAnnotationKey exposes when the credentials of a referenced
+	// Secret expire.
+	ExpiresAtAnnotationKey = "puller.io/expires-at"
+)
+
+// BasicCredentials are a username and a password, or the auth encoding both.
+type BasicCredentials struct {
+	// +kubebuilder:validation:Optional
+	Username string `json:"username,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	Password string `json:"password,omitempty"`
+
+	// Auth is the base64 encoding of username:password.
+	// +kubebuilder:validation:Optional
+	Auth string `json:"auth,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	Email string `json:"email,omitempty"`
+}
+
+// TokenCredentials are the tokens of a registry authenticating with OAuth,
+// exactly one of them must be set.
+type TokenCredentials struct {
+	// Username goes along the identity token, some registries expect a fixed
+	// one, e.g. 00000000-0000-0000-0000-000000000000 for Azure.
+	// +kubebuilder:validation:Optional
+	Username string `json:"username,omitempty"`
+
+	// IdentityToken is an OAuth refresh token the container runtime
+	// exchanges for an access token of the registry.
+	// +kubebuilder:validation:Optional
+	IdentityToken string `json:"identityToken,omitempty"`
+
+	// RegistryToken is a bearer token sent as is to the registry.
+	// +kubebuilder:validation:Optional
+	RegistryToken string `json:"registryToken,omitempty"`
+}
+
+// SecretReference references a Secret in a namespace.
+type SecretReference struct {
+	// +kubebuilder:validation:Required
+	Namespace string `json:"namespace"`
+
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+// DeletionPolicy defines how to handle the distributed objects on puller deletion.
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the managed Secrets and removes the
+	// ServiceAccount references added by the puller.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain releases the managed Secrets and keeps the
+	// ServiceAccount references in place.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// PullerStatus defines the observed state of Puller
+type PullerStatus struct {
+	// ObservedGeneration is the generation of the last distributed spec.
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Revision is the revision of the credentials the puller distributes,
+	// in the history kept by the controller.
+	// +kubebuilder:validation:Optional
+	Revision int64 `json:"revision,omitempty"`
+
+	// TargetNamespaces is the number of namespaces the puller selects.
+	// +kubebuilder:validation:Optional
+	TargetNamespaces int `json:"targetNamespaces,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Plan is the set of changes computed while the puller is in plan mode.
+	// +kubebuilder:validation:Optional
+	Plan *Plan `json:"plan,omitempty"`
+
+	// Progress is the checkpoint of a distribution in progress, a restarted
+	// controller resumes from it instead of starting over.
+	// +kubebuilder:validation:Optional
+	Progress *Progress `json:"progress,omitempty"`
+
+	// Registries lists the validity of the credentials of the registries
+	// that have one.
+	// +kubebuilder:validation:Optional
+	Registries []RegistryStatus `json:"registries,omitempty"`
+
+	// Rollout is the state of the staged rollout of the Secrets.
+	// +kubebuilder:validation:Optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+}
+
+// RegistryStatus is the validity of the credentials of a registry.
+type RegistryStatus struct {
+	// Server of the registry.
+	Server string `json:"server"`
+
+	// ValidFrom is when the credentials become valid.
+	// +kubebuilder:validation:Optional
+	ValidFrom *metav1.Time `json:"validFrom,omitempty"`
+
+	// ExpiresAt is when the credentials expire.
+	// +kubebuilder:validation:Optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// State of the credentials.
+	State CredentialsState `json:"state"`
+
+	// Warning is the shortest expiry warning raised for the credentials.
+	// +kubebuilder:validation:Optional
+	Warning *metav1.Duration `json:"warning,omitempty"`
+}
+
+// CredentialsState is the state of the credentials of a registry in their
+// validity window.
+type CredentialsState string
+
+const (
+	// CredentialsStateNotYetValid means the credentials are not distributed yet.
+	CredentialsStateNotYetValid CredentialsState = "NotYetValid"
+	// CredentialsStateValid means the credentials are distributed.
+	CredentialsStateValid CredentialsState = "Valid"
+	// CredentialsStateExpiringSoon means the credentials are distributed and
+	// expire within an expiry warning.
+	CredentialsStateExpiringSoon CredentialsState = "ExpiringSoon"
+	// CredentialsStateExpired means the credentials are not distributed anymore.
+	CredentialsStateExpired CredentialsState = "Expired"
+)
+
+// RolloutStatus records how far the staged rollout of a revision of the
+// Secrets went.
+type RolloutStatus struct {
+	// Revision identifies the content of the Secrets being rolled out.
+	// +kubebuilder:validation:Optional
+	Revision string `json:"revision,omitempty"`
+
+	// Phase of the rollout.
+	// +kubebuilder:validation:Optional
+	Phase RolloutPhase `json:"phase,omitempty"`
+
+	// Stage is the current stage of the rollout, 0 for the canary and i for
+	// the i-th batch.
+	// +kubebuilder:validation:Optional
+	Stage int32 `json:"stage,omitempty"`
+
+	// StageStartTime is when the current stage started.
+	// +kubebuilder:validation:Optional
+	StageStartTime *metav1.Time `json:"stageStartTime,omitempty"`
+
+	// UpdatedNamespaces is the number of target namespaces the revision is
+	// applied to.
+	// +kubebuilder:validation:Optional
+	UpdatedNamespaces int `json:"updatedNamespaces,omitempty"`
+
+	// TotalNamespaces is the number of target namespaces.
+	// +kubebuilder:validation:Optional
+	TotalNamespaces int `json:"totalNamespaces,omitempty"`
+
+	// Message explains the phase, e.g. why the rollout failed.
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+// RolloutPhase is the phase of a staged rollout.
+type RolloutPhase string
+
+const (
+	// RolloutPhaseProgressing means the revision is being applied stage by stage.
+	RolloutPhaseProgressing RolloutPhase = "Progressing"
+	// RolloutPhaseComplete means the revision is applied to every target namespace.
+	RolloutPhaseComplete RolloutPhase = "Complete"
+	// RolloutPhaseFailed means a registry rejected the credentials of the
+	// revision, the rollout stays stopped until the next change of the
+	// Secrets or the removal of the strategy.
+	RolloutPhaseFailed RolloutPhase = "Failed"
+)
+
+// Progress records how far the distribution of a puller went.
+type Progress struct {
+	// ObservedGeneration is the generation of the puller being distributed.
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ContentHash identifies the content of the Secrets being distributed,
+	// which also changes with the referenced credentials.
+	// +kubebuilder:validation:Optional
+	ContentHash string `json:"contentHash,omitempty"`
+
+	// Namespace is the last target namespace, in lexical order, up to which
+	// every namespace is synced.
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Synced is the number of target namespaces synced so far.
+	// +kubebuilder:validation:Optional
+	Synced int `json:"synced,omitempty"`
+
+	// Total is the number of target namespaces.
+	// +kubebuilder:validation:Optional
+	Total int `json:"total,omitempty"`
+
+	// Blocked lists the synced namespaces where an unmanaged secret blocks
+	// the distribution.
+	// +kubebuilder:validation:Optional
+	Blocked []string `json:"blocked,omitempty"`
+}
+
+// Plan describes the changes the controller would make to distribute a puller.
+type Plan struct {
+	// ObservedGeneration is the generation of the puller the plan was computed for.
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Total is the number of planned changes, which may be larger than the
+	// number of listed changes.
+	// +kubebuilder:validation:Optional
+	Total int `json:"total,omitempty"`
+
+	// Changes lists the planned changes.
+	// +kubebuilder:validation:Optional
+	Changes []PlannedChange `json:"changes,omitempty"`
+}
+
+// PlannedChange is a single write the controller would make.
+type PlannedChange struct {
+	Action PlanAction `json:"action"`
+	Kind   string     `json:"kind"`
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// PlanAction is the kind of write of a planned change.
+type PlanAction string
+
+const (
+	PlanActionCreate PlanAction = "Create"
+	PlanActionUpdate PlanAction = "Update"
+	PlanActionDelete PlanAction = "Delete"
+	PlanActionPatch  PlanAction = "Patch"
+)
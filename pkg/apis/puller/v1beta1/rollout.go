@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import "time"
+
+const (
+	// DefaultRolloutSoakDuration is the soak duration of a rollout without one.
+	DefaultRolloutSoakDuration = 10 * time.Minute
+	// DefaultRolloutBatchInterval is the batch interval of a rollout without one.
+	DefaultRolloutBatchInterval = 5 * time.Minute
+)
+
+// RolloutSoakDuration returns the soak duration of the rollout of the puller.
+func RolloutSoakDuration(puller *Puller) time.Duration {
+	if r := puller.Spec.RolloutStrategy; r != nil && r.SoakDuration != nil {
+		return r.SoakDuration.Duration
+	}
+	return DefaultRolloutSoakDuration
+}
+
+// RolloutBatchInterval returns the batch interval of the rollout of the puller.
+func RolloutBatchInterval(puller *Puller) time.Duration {
+	if r := puller.Spec.RolloutStrategy; r != nil && r.BatchInterval != nil {
+		return r.BatchInterval.Duration
+	}
+	return DefaultRolloutBatchInterval
+}
+
+// RolloutBatches returns the cumulative percentages of the batches of the
+// rollout of the puller.
+func RolloutBatches(puller *Puller) []int32 {
+	if r := puller.Spec.RolloutStrategy; r != nil && len(r.Batches) != 0 {
+		return r.Batches
+	}
+	return []int32{100}
+}
+
+// DefaultRevisionHistoryLimit is the revision history limit of a puller
+// without one.
+const DefaultRevisionHistoryLimit = 10
+
+// RevisionHistoryLimit returns the number of previous revisions of the
+// credentials of the puller that are kept.
+func RevisionHistoryLimit(puller *Puller) int {
+	if n := puller.Spec.RevisionHistoryLimit; n != nil {
+		return int(*n)
+	}
+	return DefaultRevisionHistoryLimit
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultExpiryWarnings are the expiry warnings of a puller without any.
+var DefaultExpiryWarnings = []time.Duration{7 * 24 * time.Hour, 24 * time.Hour}
+
+// ExpiryWarnings returns the expiry warnings of the puller, from the
+// longest to the shortest.
+func ExpiryWarnings(puller *Puller) []time.Duration {
+	if len(puller.Spec.ExpiryWarnings) == 0 {
+		return DefaultExpiryWarnings
+	}
+	warnings := make([]time.Duration, 0, len(puller.Spec.ExpiryWarnings))
+	for _, w := range puller.Spec.ExpiryWarnings {
+		warnings = append(warnings, w.Duration)
+	}
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i] > warnings[j]
+	})
+	return warnings
+}
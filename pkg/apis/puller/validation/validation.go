@@ -0,0 +1,380 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/registry"
+)
+
+// redacted replaces the credentials in the error messages.
+const redacted = "<redacted>"
+
+var errInvalidAuth = errors.New("must be the base64 encoding of username:password")
+
+// ValidatePuller checks a puller, the admission webhook rejects the pullers
+// it finds errors in and the controller does not distribute them.
+func ValidatePuller(puller *pullerv1beta1.Puller) field.ErrorList {
+	errs := ValidatePullerSpec(&puller.Spec, field.NewPath("spec"))
+	// the default names of the outputs derive from the name of the puller
+	errs = append(errs, validateOutputNames(puller, field.NewPath("spec", "outputs"))...)
+	return errs
+}
+
+// ValidatePullerSpec checks the spec of a puller.
+func ValidatePullerSpec(spec *pullerv1beta1.PullerSpec, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateRegistries(spec.Registries, fldPath.Child("registries"))...)
+	if spec.NamespaceSelector != nil {
+		errs = append(errs, validateSelector(spec.NamespaceSelector, fldPath.Child("namespaceSelector"))...)
+	}
+	if t := spec.SecretTemplate; t != nil {
+		fldPath := fldPath.Child("secretTemplate")
+		if t.Name != "" {
+			for _, msg := range apivalidation.NameIsDNSSubdomain(t.Name, false) {
+				errs = append(errs, field.Invalid(fldPath.Child("name"), t.Name, msg))
+			}
+		}
+		errs = append(errs, metav1validation.ValidateLabels(t.Labels, fldPath.Child("labels"))...)
+		errs = append(errs, apivalidation.ValidateAnnotations(t.Annotations, fldPath.Child("annotations"))...)
+	}
+	errs = append(errs, validateOutputs(spec, fldPath.Child("outputs"))...)
+	if r := spec.Rotation; r != nil {
+		fldPath := fldPath.Child("rotation")
+		switch r.Strategy {
+		case "", pullerv1beta1.RotationStrategyInPlace, pullerv1beta1.RotationStrategyImmutable:
+		default:
+			errs = append(errs, field.NotSupported(fldPath.Child("strategy"), r.Strategy, []string{
+				string(pullerv1beta1.RotationStrategyInPlace),
+				string(pullerv1beta1.RotationStrategyImmutable),
+			}))
+		}
+		if r.GracePeriod != nil && r.GracePeriod.Duration < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("gracePeriod"), r.GracePeriod.Duration.String(), "must be greater than or equal to 0"))
+		}
+	}
+	if r := spec.RolloutStrategy; r != nil {
+		errs = append(errs, validateRolloutStrategy(r, fldPath.Child("rolloutStrategy"))...)
+	}
+	if n := spec.RevisionHistoryLimit; n != nil && *n < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("revisionHistoryLimit"), *n, "must be greater than or equal to 0"))
+	}
+	if n := spec.RollbackTo; n != nil && *n < 1 {
+		errs = append(errs, field.Invalid(fldPath.Child("rollbackTo"), *n, "must be greater than 0"))
+	}
+	for i, w := range spec.ExpiryWarnings {
+		if w.Duration <= 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("expiryWarnings").Index(i), w.Duration.String(), "must be greater than 0"))
+		}
+	}
+	return errs
+}
+
+func validateRolloutStrategy(r *pullerv1beta1.RolloutStrategy, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if r.Canary == nil {
+		errs = append(errs, field.Required(fldPath.Child("canary"), ""))
+	} else {
+		errs = append(errs, validateSelector(r.Canary, fldPath.Child("canary"))...)
+	}
+	if r.SoakDuration != nil && r.SoakDuration.Duration < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("soakDuration"), r.SoakDuration.Duration.String(), "must be greater than or equal to 0"))
+	}
+	if r.BatchInterval != nil && r.BatchInterval.Duration < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("batchInterval"), r.BatchInterval.Duration.String(), "must be greater than or equal to 0"))
+	}
+	previous := int32(0)
+	for i, percent := range r.Batches {
+		if percent <= previous || percent > 100 {
+			errs = append(errs, field.Invalid(fldPath.Child("batches").Index(i), percent, fmt.Sprintf("must be greater than %d and less than or equal to 100", previous)))
+		}
+		previous = percent
+	}
+	if n := len(r.Batches); n != 0 && r.Batches[n-1] != 100 {
+		errs = append(errs, field.Invalid(fldPath.Child("batches").Index(n-1), r.Batches[n-1], "the last batch must be 100"))
+	}
+	return errs
+}
+
+func validateOutputs(spec *pullerv1beta1.PullerSpec, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	registries := make(map[string]*pullerv1beta1.Registry, len(spec.Registries))
+	for i := range spec.Registries {
+		registries[registry.ConfigKey(spec.Registries[i].Server)] = &spec.Registries[i]
+	}
+	for i, output := range spec.Outputs {
+		idxPath := fldPath.Index(i)
+		switch output.Format {
+		case pullerv1beta1.OutputFormatDockerConfigJSON, pullerv1beta1.OutputFormatDockerCfg, pullerv1beta1.OutputFormatConfigJSON:
+			if output.Server != "" {
+				errs = append(errs, field.Forbidden(idxPath.Child("server"), "may only be set for the FluxHelmRepository and ArgoCDRepository formats"))
+			}
+		case pullerv1beta1.OutputFormatFluxHelmRepository, pullerv1beta1.OutputFormatArgoCDRepository:
+			errs = append(errs, validateOutputServer(output.Server, registries, idxPath.Child("server"))...)
+		default:
+			errs = append(errs, field.NotSupported(idxPath.Child("format"), output.Format, []string{
+				string(pullerv1beta1.OutputFormatDockerConfigJSON),
+				string(pullerv1beta1.OutputFormatDockerCfg),
+				string(pullerv1beta1.OutputFormatConfigJSON),
+				string(pullerv1beta1.OutputFormatFluxHelmRepository),
+				string(pullerv1beta1.OutputFormatArgoCDRepository),
+			}))
+		}
+		if output.Name != "" {
+			for _, msg := range apivalidation.NameIsDNSSubdomain(output.Name, false) {
+				errs = append(errs, field.Invalid(idxPath.Child("name"), output.Name, msg))
+			}
+		}
+	}
+	return errs
+}
+
+// validateOutputNames checks that the outputs, which are distributed to the
+// same namespaces, have distinct names once defaulted.
+func validateOutputNames(puller *pullerv1beta1.Puller, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	immutable := pullerv1beta1.ImmutableSecrets(puller)
+	names := make(map[string]bool, len(puller.Spec.Outputs))
+	for i, output := range pullerv1beta1.OutputsOf(puller) {
+		if names[output.Name] {
+			errs = append(errs, field.Duplicate(fldPath.Index(i).Child("name"), output.Name))
+		}
+		names[output.Name] = true
+		// the versions of an immutable image pull secret are suffixed
+		if immutable && output.Format.IsPullSecret() && len(output.Name)+1+pullerv1beta1.VersionHashLength > validation.DNS1123SubdomainMaxLength {
+			errs = append(errs, field.TooLong(fldPath.Index(i).Child("name"), output.Name, validation.DNS1123SubdomainMaxLength-1-pullerv1beta1.VersionHashLength))
+		}
+	}
+	return errs
+}
+
+// validateOutputServer checks the server of an output holding the username
+// and password of a single registry.
+func validateOutputServer(server string, registries map[string]*pullerv1beta1.Registry, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if server == "" {
+		return append(errs, field.Required(fldPath, "the registry of the credentials must be set"))
+	}
+	r, ok := registries[registry.ConfigKey(server)]
+	switch {
+	case !ok:
+		errs = append(errs, field.NotFound(fldPath, server))
+	case strings.Contains(r.Server, registry.Wildcard):
+		errs = append(errs, field.Invalid(fldPath, server, "must not be a wildcard server"))
+	case r.Credentials.Token != nil:
+		errs = append(errs, field.Invalid(fldPath, server, "must have a username and a password, not a token"))
+	}
+	return errs
+}
+
+func validateRegistries(registries []pullerv1beta1.Registry, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	servers := make(map[string]bool, len(registries))
+	for i := range registries {
+		r := &registries[i]
+		idxPath := fldPath.Index(i)
+		errs = append(errs, validateRegistry(r, idxPath)...)
+
+		// the servers are keys of the docker config, a duplicate overrides
+		// the credentials of the previous entry
+		if registry.NormalizeServer(r.Server) == "" {
+			continue
+		}
+		server := registry.ConfigKey(r.Server)
+		if servers[server] {
+			errs = append(errs, field.Duplicate(idxPath.Child("server"), r.Server))
+		}
+		servers[server] = true
+	}
+	return errs
+}
+
+func validateRegistry(r *pullerv1beta1.Registry, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateServer(r.Server, fldPath.Child("server"))...)
+	if r.ValidFrom != nil && r.ExpiresAt != nil && !r.ValidFrom.Before(r.ExpiresAt) {
+		errs = append(errs, field.Invalid(fldPath.Child("expiresAt"), r.ExpiresAt.UTC().Format(time.RFC3339), "must be after validFrom"))
+	}
+
+	fldPath = fldPath.Child("credentials")
+	c := r.Credentials
+	sources := 0
+	if c.Basic != nil {
+		sources++
+		errs = append(errs, validateBasicCredentials(c.Basic, fldPath.Child("basic"))...)
+	}
+	if c.Token != nil {
+		sources++
+		errs = append(errs, validateTokenCredentials(c.Token, fldPath.Child("token"))...)
+	}
+	if c.SecretRef != nil {
+		sources++
+		errs = append(errs, validateSecretReference(c.SecretRef, fldPath.Child("secretRef"))...)
+	}
+	// a registry without credentials, which v1alpha1 accepted, is not
+	// distributed and is reported on the status by the controller
+	if sources > 1 {
+		errs = append(errs, field.Forbidden(fldPath, "only one of basic, token or secretRef may be set"))
+	}
+	return errs
+}
+
+func validateBasicCredentials(c *pullerv1beta1.BasicCredentials, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	switch {
+	case c.Auth != "":
+		username, password, err := decodeAuth(c.Auth)
+		if err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child("auth"), redacted, err.Error()))
+			break
+		}
+		// username and password are redundant with auth, they must agree
+		if c.Username != "" && c.Username != username {
+			errs = append(errs, field.Invalid(fldPath.Child("username"), c.Username, "must match the username of auth"))
+		}
+		if c.Password != "" && c.Password != password {
+			errs = append(errs, field.Invalid(fldPath.Child("password"), redacted, "must match the password of auth"))
+		}
+	case c.Username == "" && c.Password != "":
+		errs = append(errs, field.Required(fldPath.Child("username"), "must be set with password"))
+	case c.Username == "":
+		errs = append(errs, field.Required(fldPath.Child("auth"), "auth or username must be set"))
+	}
+	return errs
+}
+
+func validateTokenCredentials(c *pullerv1beta1.TokenCredentials, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	switch {
+	case c.IdentityToken != "" && c.RegistryToken != "":
+		errs = append(errs, field.Forbidden(fldPath.Child("registryToken"), "may not be set with identityToken"))
+	case c.IdentityToken == "" && c.RegistryToken == "":
+		errs = append(errs, field.Required(fldPath, "one of identityToken or registryToken must be set"))
+	case c.RegistryToken != "" && c.Username != "":
+		// a bearer token authenticates alone
+		errs = append(errs, field.Forbidden(fldPath.Child("username"), "may not be set with registryToken"))
+	}
+	return errs
+}
+
+func validateSecretReference(ref *pullerv1beta1.SecretReference, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	for _, msg := range apivalidation.ValidateNamespaceName(ref.Namespace, false) {
+		errs = append(errs, field.Invalid(fldPath.Child("namespace"), ref.Namespace, msg))
+	}
+	for _, msg := range apivalidation.NameIsDNSSubdomain(ref.Name, false) {
+		errs = append(errs, field.Invalid(fldPath.Child("name"), ref.Name, msg))
+	}
+	return errs
+}
+
+// validateServer checks a registry server: a host name, whose leftmost
+// labels may be wildcards, or an IP address, with an optional port,
+// followed by an optional repository path.
+func validateServer(server string, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	normalized := registry.NormalizeServer(server)
+	if normalized == "" {
+		return append(errs, field.Required(fldPath, ""))
+	}
+	host, path := registry.SplitServer(normalized)
+	if h, port, err := net.SplitHostPort(host); err == nil {
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			errs = append(errs, field.Invalid(fldPath, server, "must have a port between 1 and 65535"))
+		}
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if net.ParseIP(host) == nil {
+		errs = append(errs, validateHostName(host, server, fldPath)...)
+	}
+	if path != "" {
+		for _, segment := range strings.Split(path, "/") {
+			if !pathComponentRegexp.MatchString(segment) {
+				errs = append(errs, field.Invalid(fldPath, server, "must have a repository path of lower case alphanumeric components separated by '/'"))
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// pathComponentRegexp matches a component of a repository path.
+var pathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*$`)
+
+// validateHostName checks a host name whose leftmost labels may be
+// wildcards, which the kubelet matches against a single label each. At
+// least two labels must follow the wildcards, so that a server does not
+// match a whole top level domain.
+func validateHostName(host, server string, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	labels := strings.Split(host, ".")
+	wildcards := 0
+	for wildcards < len(labels) && labels[wildcards] == registry.Wildcard {
+		wildcards++
+	}
+	if wildcards == 0 {
+		if len(validation.IsDNS1123Subdomain(host)) != 0 {
+			errs = append(errs, field.Invalid(fldPath, server, "must have an IP address or a host name as host"))
+		}
+		return errs
+	}
+	rest := strings.Join(labels[wildcards:], ".")
+	if len(labels)-wildcards < 2 || len(validation.IsDNS1123Subdomain(rest)) != 0 {
+		errs = append(errs, field.Invalid(fldPath, server, "must have a host name of at least two labels after the leading wildcards"))
+	}
+	return errs
+}
+
+// decodeAuth decodes the auth field of a docker config entry.
+func decodeAuth(auth string) (username, password string, err error) {
+	decoded, err := base64.StdEncoding.DecodeString(auth)
+	if err != nil {
+		return "", "", errInvalidAuth
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok || username == "" {
+		return "", "", errInvalidAuth
+	}
+	return username, password, nil
+}
+
+func validateSelector(selector *metav1.LabelSelector, fldPath *field.Path) field.ErrorList {
+	errs := metav1validation.ValidateLabelSelector(selector, metav1validation.LabelSelectorValidationOptions{}, fldPath)
+	if len(errs) != 0 {
+		return errs
+	}
+	if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+		errs = append(errs, field.Invalid(fldPath, selector, err.Error()))
+	}
+	return errs
+}
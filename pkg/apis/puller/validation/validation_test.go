@@ -0,0 +1,273 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+func TestValidatePuller(t *testing.T) {
+	basic := func(username, password string) pullerv1beta1.RegistryCredentials {
+		return pullerv1beta1.RegistryCredentials{
+			Basic: &pullerv1beta1.BasicCredentials{Username: username, Password: password},
+		}
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		mutate     func(p *pullerv1beta1.Puller)
+		wantFields []string
+	}{
+		{
+			name:   "basic credentials are valid",
+			mutate: func(p *pullerv1beta1.Puller) {},
+		},
+		{
+			name: "registry without credentials is accepted",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries[0].Credentials = pullerv1beta1.RegistryCredentials{}
+			},
+		},
+		{
+			name: "several credential sources",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries[0].Credentials.Token = &pullerv1beta1.TokenCredentials{IdentityToken: "token"}
+			},
+			wantFields: []string{"spec.registries[0].credentials"},
+		},
+		{
+			name: "password without username",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries[0].Credentials = basic("", "secret")
+			},
+			wantFields: []string{"spec.registries[0].credentials.basic.username"},
+		},
+		{
+			name: "auth disagreeing with username",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries[0].Credentials.Basic.Auth = base64.StdEncoding.EncodeToString([]byte("other:secret"))
+			},
+			wantFields: []string{"spec.registries[0].credentials.basic.username"},
+		},
+		{
+			name: "auth that is not base64",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries[0].Credentials = pullerv1beta1.RegistryCredentials{
+					Basic: &pullerv1beta1.BasicCredentials{Auth: "not base64"},
+				}
+			},
+			wantFields: []string{"spec.registries[0].credentials.basic.auth"},
+		},
+		{
+			name: "registry token with username",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries[0].Credentials = pullerv1beta1.RegistryCredentials{
+					Token: &pullerv1beta1.TokenCredentials{Username: "user", RegistryToken: "token"},
+				}
+			},
+			wantFields: []string{"spec.registries[0].credentials.token.username"},
+		},
+		{
+			name: "invalid secret reference",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries[0].Credentials = pullerv1beta1.RegistryCredentials{
+					SecretRef: &pullerv1beta1.SecretReference{Namespace: "Default", Name: "creds"},
+				}
+			},
+			wantFields: []string{"spec.registries[0].credentials.secretRef.namespace"},
+		},
+		{
+			name: "missing server",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries[0].Server = ""
+			},
+			wantFields: []string{"spec.registries[0].server"},
+		},
+		{
+			name: "invalid servers",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries = append(p.Spec.Registries,
+					pullerv1beta1.Registry{Server: "harbor.corp:70000", Credentials: basic("user", "secret")},
+					pullerv1beta1.Registry{Server: "*.io", Credentials: basic("user", "secret")},
+					pullerv1beta1.Registry{Server: "harbor.corp/Team", Credentials: basic("user", "secret")},
+				)
+			},
+			wantFields: []string{"spec.registries[1].server", "spec.registries[2].server", "spec.registries[3].server"},
+		},
+		{
+			name: "wildcard and path servers",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries = append(p.Spec.Registries,
+					pullerv1beta1.Registry{Server: "*.azurecr.io", Credentials: basic("user", "secret")},
+					pullerv1beta1.Registry{Server: "https://harbor.corp/team-a/", Credentials: basic("user", "secret")},
+				)
+			},
+		},
+		{
+			name: "servers with the same config key",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries = append(p.Spec.Registries,
+					pullerv1beta1.Registry{Server: "https://release.daocloud.io/", Credentials: basic("user", "secret")},
+				)
+			},
+			wantFields: []string{"spec.registries[1].server"},
+		},
+		{
+			name: "docker hub spellings share a key",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries = []pullerv1beta1.Registry{
+					{Server: "docker.io", Credentials: basic("user", "secret")},
+					{Server: "https://index.docker.io/v1/", Credentials: basic("user", "secret")},
+				}
+			},
+			wantFields: []string{"spec.registries[1].server"},
+		},
+		{
+			name: "expiry before the start of the validity",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries[0].ValidFrom = &metav1.Time{Time: now}
+				p.Spec.Registries[0].ExpiresAt = &metav1.Time{Time: now.Add(-time.Hour)}
+			},
+			wantFields: []string{"spec.registries[0].expiresAt"},
+		},
+		{
+			name: "output server of a token registry",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Registries[0].Credentials = pullerv1beta1.RegistryCredentials{
+					Token: &pullerv1beta1.TokenCredentials{IdentityToken: "token"},
+				}
+				p.Spec.Outputs = []pullerv1beta1.Output{
+					{Format: pullerv1beta1.OutputFormatArgoCDRepository, Server: "release.daocloud.io"},
+				}
+			},
+			wantFields: []string{"spec.outputs[0].server"},
+		},
+		{
+			name: "output server of an unknown registry",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Outputs = []pullerv1beta1.Output{
+					{Format: pullerv1beta1.OutputFormatFluxHelmRepository, Server: "harbor.corp"},
+				}
+			},
+			wantFields: []string{"spec.outputs[0].server"},
+		},
+		{
+			name: "server of a pull secret output",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Outputs = []pullerv1beta1.Output{
+					{Format: pullerv1beta1.OutputFormatDockerConfigJSON, Server: "release.daocloud.io"},
+				}
+			},
+			wantFields: []string{"spec.outputs[0].server"},
+		},
+		{
+			name: "outputs with the same name",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Outputs = []pullerv1beta1.Output{
+					{Format: pullerv1beta1.OutputFormatDockerConfigJSON, Name: "pull"},
+					{Format: pullerv1beta1.OutputFormatDockerCfg, Name: "pull"},
+				}
+			},
+			wantFields: []string{"spec.outputs[1].name"},
+		},
+		{
+			name: "immutable output name too long for its versions",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Rotation = &pullerv1beta1.Rotation{Strategy: pullerv1beta1.RotationStrategyImmutable}
+				p.Spec.Outputs = []pullerv1beta1.Output{
+					{Format: pullerv1beta1.OutputFormatDockerConfigJSON, Name: strings.Repeat("a", 250)},
+				}
+			},
+			wantFields: []string{"spec.outputs[0].name"},
+		},
+		{
+			name: "unknown rotation strategy",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Rotation = &pullerv1beta1.Rotation{Strategy: "Shuffle"}
+			},
+			wantFields: []string{"spec.rotation.strategy"},
+		},
+		{
+			name: "rollout batches",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.RolloutStrategy = &pullerv1beta1.RolloutStrategy{
+					Canary:  &metav1.LabelSelector{MatchLabels: map[string]string{"canary": "true"}},
+					Batches: []int32{50, 25, 75},
+				}
+			},
+			wantFields: []string{"spec.rolloutStrategy.batches[1]", "spec.rolloutStrategy.batches[2]"},
+		},
+		{
+			name: "rollout without canary",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.RolloutStrategy = &pullerv1beta1.RolloutStrategy{Batches: []int32{50, 100}}
+			},
+			wantFields: []string{"spec.rolloutStrategy.canary"},
+		},
+		{
+			name: "negative history limit and rollback",
+			mutate: func(p *pullerv1beta1.Puller) {
+				limit, rollback := int32(-1), int64(0)
+				p.Spec.RevisionHistoryLimit, p.Spec.RollbackTo = &limit, &rollback
+			},
+			wantFields: []string{"spec.revisionHistoryLimit", "spec.rollbackTo"},
+		},
+		{
+			name: "zero expiry warning",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.ExpiryWarnings = []metav1.Duration{{Duration: time.Hour}, {}}
+			},
+			wantFields: []string{"spec.expiryWarnings[1]"},
+		},
+		{
+			name: "invalid namespace selector",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a b"}}
+			},
+			wantFields: []string{"spec.namespaceSelector.matchLabels"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puller := &pullerv1beta1.Puller{
+				ObjectMeta: metav1.ObjectMeta{Name: "puller"},
+				Spec: pullerv1beta1.PullerSpec{
+					Registries: []pullerv1beta1.Registry{
+						{Server: "release.daocloud.io", Credentials: basic("user", "secret")},
+					},
+				},
+			}
+			tt.mutate(puller)
+			var fields []string
+			for _, err := range ValidatePuller(puller) {
+				fields = append(fields, err.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("ValidatePuller() fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package puller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	applymetav1 "k8s.io/client-go/applyconfigurations/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// secretApplyConfiguration builds the apply configuration of a secret,
+// every field set on the secret is owned by the puller field manager.
+func secretApplyConfiguration(secret *corev1.Secret) *applycorev1.SecretApplyConfiguration {
+	ac := applycorev1.Secret(secret.Name, secret.Namespace).
+		WithLabels(secret.Labels).
+		WithAnnotations(secret.Annotations).
+		WithType(secret.Type).
+		WithData(secret.Data)
+	if secret.Immutable != nil {
+		ac.WithImmutable(*secret.Immutable)
+	}
+	for _, ref := range secret.OwnerReferences {
+		refAC := applymetav1.OwnerReference().
+			WithAPIVersion(ref.APIVersion).
+			WithKind(ref.Kind).
+			WithName(ref.Name).
+			WithUID(ref.UID)
+		if ref.Controller != nil {
+			refAC.WithController(*ref.Controller)
+		}
+		if ref.BlockOwnerDeletion != nil {
+			refAC.WithBlockOwnerDeletion(*ref.BlockOwnerDeletion)
+		}
+		ac.WithOwnerReferences(refAC)
+	}
+	return ac
+}
+
+// setContentHash annotates the secret with the hash of its content.
+func setContentHash(secret *corev1.Secret) error {
+	delete(secret.Annotations, ContentHashAnnotationKey)
+	content, err := json.Marshal(struct {
+		Labels          map[string]string       `json:"labels,omitempty"`
+		Annotations     map[string]string       `json:"annotations,omitempty"`
+		OwnerReferences []metav1.OwnerReference `json:"ownerReferences,omitempty"`
+		Type            corev1.SecretType       `json:"type"`
+		Immutable       *bool                   `json:"immutable,omitempty"`
+		Data            map[string][]byte       `json:"data,omitempty"`
+	}{
+		Labels:          secret.Labels,
+		Annotations:     secret.Annotations,
+		OwnerReferences: secret.OwnerReferences,
+		Type:            secret.Type,
+		Immutable:       secret.Immutable,
+		Data:            secret.Data,
+	})
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(content)
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string, 1)
+	}
+	secret.Annotations[ContentHashAnnotationKey] = hex.EncodeToString(sum[:])
+	return nil
+}
+
+// patchServiceAccount patches a service account with the strategic merge patch
+// built from its current state, nil means nothing to patch. The patch carries
+// the resource version it was built from, so it fails on concurrent changes
+// and is rebuilt from a live read.
+func (c *Controller) patchServiceAccount(ctx context.Context, key types.NamespacedName, buildPatch func(*corev1.ServiceAccount) ([]byte, error)) error {
+	reader := client.Reader(c.Client)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		got := &corev1.ServiceAccount{}
+		err := reader.Get(ctx, key, got)
+		// the cache may be stale after a conflict
+		reader = c.APIReader
+		if err != nil && apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		patch, err := buildPatch(got)
+		if err != nil || patch == nil {
+			return err
+		}
+		if err := c.waitForWrite(ctx); err != nil {
+			return err
+		}
+		_, err = c.KubeClient.CoreV1().ServiceAccounts(key.Namespace).Patch(ctx, key.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{
+			FieldManager: FieldManager,
+		})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	})
+}
+
+// serviceAccountPatch builds a strategic merge patch that adds and removes
+// image pull secret references and records the secrets managed by puller,
+// leaving every other field of the service account untouched. The image pull
+// secrets of a service account have no merge strategy, so the patch replaces
+// them with the whole list, the resource version guards the references
+// added by others in the meantime.
+func serviceAccountPatch(sa *corev1.ServiceAccount, managed sets.Set[string], add, remove []string) ([]byte, error) {
+	metadata := map[string]interface{}{
+		"resourceVersion": sa.ResourceVersion,
+	}
+	if managed.Len() == 0 {
+		metadata["labels"] = map[string]interface{}{ManagedLabelKey: nil}
+		metadata["annotations"] = map[string]interface{}{ManagedSecretsAnnotationKey: nil}
+	} else {
+		metadata["labels"] = map[string]interface{}{ManagedLabelKey: "true"}
+		metadata["annotations"] = map[string]interface{}{ManagedSecretsAnnotationKey: strings.Join(sets.List(managed), ",")}
+	}
+
+	patch := map[string]interface{}{"metadata": metadata}
+	if len(add) != 0 || len(remove) != 0 {
+		removed := sets.New[string](remove...)
+		refs := make([]corev1.LocalObjectReference, 0, len(sa.ImagePullSecrets)+len(add))
+		for _, ref := range sa.ImagePullSecrets {
+			if !removed.Has(ref.Name) {
+				refs = append(refs, ref)
+			}
+		}
+		for _, name := range add {
+			if !hasImagePullSecret(sa, name) && !removed.Has(name) {
+				refs = append(refs, corev1.LocalObjectReference{Name: name})
+			}
+		}
+		patch["imagePullSecrets"] = refs
+	}
+	return json.Marshal(patch)
+}
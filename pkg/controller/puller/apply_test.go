@@ -0,0 +1,103 @@
+package puller
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+func TestServiceAccountPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		refs        []string
+		managed     []string
+		add, remove []string
+		wantRefs    []string
+		wantManaged string
+	}{
+		{
+			name:        "add keeps the references of others",
+			refs:        []string{"own"},
+			managed:     []string{"pull"},
+			add:         []string{"pull"},
+			wantRefs:    []string{"own", "pull"},
+			wantManaged: "pull",
+		},
+		{
+			name:        "add of a present reference is not duplicated",
+			refs:        []string{"pull", "own"},
+			managed:     []string{"pull"},
+			add:         []string{"pull"},
+			wantRefs:    []string{"pull", "own"},
+			wantManaged: "pull",
+		},
+		{
+			name:        "remove keeps the references of others",
+			refs:        []string{"own", "pull", "other"},
+			remove:      []string{"pull"},
+			wantRefs:    []string{"own", "other"},
+			wantManaged: "",
+		},
+		{
+			name:        "replace a version by the next one",
+			refs:        []string{"pull-aaaa", "own"},
+			managed:     []string{"pull-bbbb"},
+			add:         []string{"pull-bbbb"},
+			remove:      []string{"pull-aaaa"},
+			wantRefs:    []string{"own", "pull-bbbb"},
+			wantManaged: "pull-bbbb",
+		},
+		{
+			name:        "release without removal keeps the references",
+			refs:        []string{"own", "pull"},
+			wantRefs:    []string{"own", "pull"},
+			wantManaged: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+				Name:            "default",
+				Namespace:       "default",
+				ResourceVersion: "1",
+			}}
+			for _, name := range tt.refs {
+				sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+			}
+			patch, err := serviceAccountPatch(sa, sets.New[string](tt.managed...), tt.add, tt.remove)
+			if err != nil {
+				t.Fatalf("serviceAccountPatch() error = %v", err)
+			}
+			original, err := json.Marshal(sa)
+			if err != nil {
+				t.Fatal(err)
+			}
+			patched, err := strategicpatch.StrategicMergePatch(original, patch, &corev1.ServiceAccount{})
+			if err != nil {
+				t.Fatalf("failed to apply patch %s: %v", patch, err)
+			}
+			got := &corev1.ServiceAccount{}
+			if err := json.Unmarshal(patched, got); err != nil {
+				t.Fatal(err)
+			}
+			var refs []string
+			for _, ref := range got.ImagePullSecrets {
+				refs = append(refs, ref.Name)
+			}
+			if !reflect.DeepEqual(refs, tt.wantRefs) {
+				t.Errorf("image pull secrets = %v, want %v", refs, tt.wantRefs)
+			}
+			if managed := got.Annotations[ManagedSecretsAnnotationKey]; managed != tt.wantManaged {
+				t.Errorf("managed secrets = %q, want %q", managed, tt.wantManaged)
+			}
+			if _, labelled := got.Labels[ManagedLabelKey]; labelled != (tt.wantManaged != "") {
+				t.Errorf("managed label = %v, want %v", labelled, tt.wantManaged != "")
+			}
+		})
+	}
+}
@@ -3,40 +3,84 @@ package puller
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	pullerv1alpha1 "github.com/puller-io/puller/pkg/apis/puller/v1alpha1"
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
 )
 
 const (
-	ConditionTypeReady = "Ready"
-	ConditionTypeError = "Error"
+	ConditionTypeReady              = "Ready"
+	ConditionTypeError              = "Error"
+	ConditionTypeSecretConflict     = "SecretConflict"
+	ConditionTypeSuspended          = "Suspended"
+	ConditionTypeExpiringSoon       = "ExpiringSoon"
+	ConditionTypeCredentialsMissing = "CredentialsMissing"
 )
 
 // SetReadyCondition - shortcut to set ready condition to true
-func SetReadyCondition(appStatus *pullerv1alpha1.PullerStatus, reason, message string) {
+func SetReadyCondition(appStatus *pullerv1beta1.PullerStatus, reason, message string) {
 	setCondition(appStatus, ConditionTypeReady, metav1.ConditionTrue, reason, message)
 }
 
 // SetNotReadyCondition - shortcut to set ready condition to false
-func SetNotReadyCondition(appStatus *pullerv1alpha1.PullerStatus, reason, message string) {
+func SetNotReadyCondition(appStatus *pullerv1beta1.PullerStatus, reason, message string) {
 	setCondition(appStatus, ConditionTypeReady, metav1.ConditionFalse, reason, message)
 }
 
 // SetReadyUnknownCondition - shortcut to set ready condition to unknown
-func SetReadyUnknownCondition(appStatus *pullerv1alpha1.PullerStatus, reason, message string) {
+func SetReadyUnknownCondition(appStatus *pullerv1beta1.PullerStatus, reason, message string) {
 	setCondition(appStatus, ConditionTypeReady, metav1.ConditionUnknown, reason, message)
 }
 
 // SetErrorCondition - shortcut to set error condition
-func SetErrorCondition(appStatus *pullerv1alpha1.PullerStatus, reason, message string) {
+func SetErrorCondition(appStatus *pullerv1beta1.PullerStatus, reason, message string) {
 	setCondition(appStatus, ConditionTypeError, metav1.ConditionTrue, reason, message)
 }
 
 // ClearErrorCondition - shortcut to set error condition
-func ClearErrorCondition(appStatus *pullerv1alpha1.PullerStatus) {
+func ClearErrorCondition(appStatus *pullerv1beta1.PullerStatus) {
 	setCondition(appStatus, ConditionTypeError, metav1.ConditionFalse, "NoError", "No error seen")
 }
 
-func setCondition(appStatus *pullerv1alpha1.PullerStatus, ctype string, status metav1.ConditionStatus, reason, message string) {
+// SetSecretConflictCondition - shortcut to set secret conflict condition
+func SetSecretConflictCondition(appStatus *pullerv1beta1.PullerStatus, reason, message string) {
+	setCondition(appStatus, ConditionTypeSecretConflict, metav1.ConditionTrue, reason, message)
+}
+
+// ClearSecretConflictCondition - shortcut to clear secret conflict condition
+func ClearSecretConflictCondition(appStatus *pullerv1beta1.PullerStatus) {
+	setCondition(appStatus, ConditionTypeSecretConflict, metav1.ConditionFalse, "NoConflict", "No secret conflict seen")
+}
+
+// SetSuspendedCondition - shortcut to set suspended condition
+func SetSuspendedCondition(appStatus *pullerv1beta1.PullerStatus, reason, message string) {
+	setCondition(appStatus, ConditionTypeSuspended, metav1.ConditionTrue, reason, message)
+}
+
+// ClearSuspendedCondition - shortcut to clear suspended condition
+func ClearSuspendedCondition(appStatus *pullerv1beta1.PullerStatus) {
+	setCondition(appStatus, ConditionTypeSuspended, metav1.ConditionFalse, "Resumed", "Puller is not suspended")
+}
+
+// SetExpiringSoonCondition - shortcut to set expiring soon condition
+func SetExpiringSoonCondition(appStatus *pullerv1beta1.PullerStatus, reason, message string) {
+	setCondition(appStatus, ConditionTypeExpiringSoon, metav1.ConditionTrue, reason, message)
+}
+
+// ClearExpiringSoonCondition - shortcut to clear expiring soon condition
+func ClearExpiringSoonCondition(appStatus *pullerv1beta1.PullerStatus) {
+	setCondition(appStatus, ConditionTypeExpiringSoon, metav1.ConditionFalse, "NoExpiringCredentials", "No credentials expiring soon")
+}
+
+// SetCredentialsMissingCondition - shortcut to set credentials missing condition
+func SetCredentialsMissingCondition(appStatus *pullerv1beta1.PullerStatus, reason, message string) {
+	setCondition(appStatus, ConditionTypeCredentialsMissing, metav1.ConditionTrue, reason, message)
+}
+
+// ClearCredentialsMissingCondition - shortcut to clear credentials missing condition
+func ClearCredentialsMissingCondition(appStatus *pullerv1beta1.PullerStatus) {
+	setCondition(appStatus, ConditionTypeCredentialsMissing, metav1.ConditionFalse, "CredentialsSet", "All registries have credentials")
+}
+
+func setCondition(appStatus *pullerv1beta1.PullerStatus, ctype string, status metav1.ConditionStatus, reason, message string) {
 	for i, c := range appStatus.Conditions {
 		if c.Type == ctype {
 			if c.Status == status && c.Reason == reason && c.Message == message {
@@ -54,7 +98,7 @@ func setCondition(appStatus *pullerv1alpha1.PullerStatus, ctype string, status m
 	addCondition(appStatus, ctype, status, reason, message)
 }
 
-func addCondition(appStatus *pullerv1alpha1.PullerStatus, ctype string, status metav1.ConditionStatus, reason, message string) {
+func addCondition(appStatus *pullerv1beta1.PullerStatus, ctype string, status metav1.ConditionStatus, reason, message string) {
 	now := metav1.Now()
 	c := metav1.Condition{
 		Type:               ctype,
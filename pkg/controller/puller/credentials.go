@@ -0,0 +1,135 @@
+package puller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/tracing"
+)
+
+// resolveCredentials returns a copy of the puller whose registries hold the
+// credentials referenced from Secrets inline, with the validity window the
+// Secrets expose. The copy only renders the distributed secrets, it is never
+// written back.
+func (c *Controller) resolveCredentials(ctx context.Context, puller *pullerv1beta1.Puller) (*pullerv1beta1.Puller, error) {
+	resolved := puller.DeepCopy()
+	for i := range resolved.Spec.Registries {
+		r := &resolved.Spec.Registries[i]
+		if r.Credentials.SecretRef == nil {
+			continue
+		}
+		if err := c.getCredentials(ctx, r); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// withoutMissingCredentials returns a copy of the rendered puller without
+// the registries that have no credentials, and their servers. Such
+// registries were accepted by v1alpha1, they are reported rather than
+// rejected.
+func withoutMissingCredentials(rendered *pullerv1beta1.Puller) (*pullerv1beta1.Puller, []string) {
+	kept := rendered.DeepCopy()
+	kept.Spec.Registries = kept.Spec.Registries[:0]
+	var missing []string
+	for i := range rendered.Spec.Registries {
+		r := &rendered.Spec.Registries[i]
+		if c := r.Credentials; c.Basic == nil && c.Token == nil && c.SecretRef == nil {
+			missing = append(missing, r.Server)
+			continue
+		}
+		kept.Spec.Registries = append(kept.Spec.Registries, *r.DeepCopy())
+	}
+	return kept, missing
+}
+
+// getCredentials reads the credentials of a registry from the referenced
+// secret, and the bounds of their validity window the spec does not set.
+func (c *Controller) getCredentials(ctx context.Context, r *pullerv1beta1.Registry) (err error) {
+	ref := r.Credentials.SecretRef
+	ctx, span := tracing.Start(ctx, "getCredentials", attribute.String("registry", r.Server),
+		attribute.String("secret", ref.Namespace+"/"+ref.Name))
+	defer func() { tracing.End(span, err) }()
+
+	// the referenced secrets are not managed by puller, so not cached
+	secret := &corev1.Secret{}
+	if err := c.APIReader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, secret); err != nil {
+		return fmt.Errorf("failed to get the credentials of registry %s: %w", r.Server, err)
+	}
+	credentials, err := credentialsFromSecret(secret)
+	if err != nil {
+		return fmt.Errorf("secret %s/%s of registry %s %w", ref.Namespace, ref.Name, r.Server, err)
+	}
+	validFrom, expiresAt, err := validityFromSecret(secret)
+	if err != nil {
+		return fmt.Errorf("secret %s/%s of registry %s %w", ref.Namespace, ref.Name, r.Server, err)
+	}
+	r.Credentials = *credentials
+	if r.ValidFrom == nil {
+		r.ValidFrom = validFrom
+	}
+	if r.ExpiresAt == nil {
+		r.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+// validityFromSecret reads the validity window the provider of the
+// credentials of a referenced secret exposes in its annotations, nil for a
+// missing bound.
+func validityFromSecret(secret *corev1.Secret) (validFrom, expiresAt *metav1.Time, err error) {
+	parse := func(key string) (*metav1.Time, error) {
+		value, ok := secret.Annotations[key]
+		if !ok {
+			return nil, nil
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("has an invalid %s annotation: %w", key, err)
+		}
+		return &metav1.Time{Time: t}, nil
+	}
+	if validFrom, err = parse(pullerv1beta1.ValidFromAnnotationKey); err != nil {
+		return nil, nil, err
+	}
+	if expiresAt, err = parse(pullerv1beta1.ExpiresAtAnnotationKey); err != nil {
+		return nil, nil, err
+	}
+	return validFrom, expiresAt, nil
+}
+
+// credentialsFromSecret reads the credentials of a referenced secret: a
+// token when it holds one, a username and a password otherwise.
+func credentialsFromSecret(secret *corev1.Secret) (*pullerv1beta1.RegistryCredentials, error) {
+	username := string(secret.Data[corev1.BasicAuthUsernameKey])
+	identityToken := string(secret.Data[pullerv1beta1.IdentityTokenKey])
+	registryToken := string(secret.Data[pullerv1beta1.RegistryTokenKey])
+	switch {
+	case identityToken != "" && registryToken != "":
+		return nil, fmt.Errorf("holds both the %s and %s keys", pullerv1beta1.IdentityTokenKey, pullerv1beta1.RegistryTokenKey)
+	case identityToken != "":
+		return &pullerv1beta1.RegistryCredentials{
+			Token: &pullerv1beta1.TokenCredentials{Username: username, IdentityToken: identityToken},
+		}, nil
+	case registryToken != "":
+		return &pullerv1beta1.RegistryCredentials{
+			Token: &pullerv1beta1.TokenCredentials{RegistryToken: registryToken},
+		}, nil
+	case username == "":
+		return nil, fmt.Errorf("has no %s key", corev1.BasicAuthUsernameKey)
+	}
+	return &pullerv1beta1.RegistryCredentials{
+		Basic: &pullerv1beta1.BasicCredentials{
+			Username: username,
+			Password: string(secret.Data[corev1.BasicAuthPasswordKey]),
+		},
+	}, nil
+}
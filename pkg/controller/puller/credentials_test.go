@@ -0,0 +1,95 @@
+package puller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+func TestResolveCredentialsValidity(t *testing.T) {
+	specExpiry := metav1.NewTime(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		expiresAt     *metav1.Time
+		wantValidFrom string
+		wantExpiresAt string
+		wantErr       bool
+	}{
+		{
+			name: "no window exposed",
+		},
+		{
+			name: "window exposed by the provider",
+			annotations: map[string]string{
+				pullerv1beta1.ValidFromAnnotationKey: "2026-01-01T00:00:00Z",
+				pullerv1beta1.ExpiresAtAnnotationKey: "2026-02-01T00:00:00+01:00",
+			},
+			wantValidFrom: "2026-01-01T00:00:00Z",
+			wantExpiresAt: "2026-01-31T23:00:00Z",
+		},
+		{
+			name:          "spec over the provider",
+			annotations:   map[string]string{pullerv1beta1.ExpiresAtAnnotationKey: "2026-02-01T00:00:00Z"},
+			expiresAt:     &specExpiry,
+			wantExpiresAt: "2027-01-01T00:00:00Z",
+		},
+		{
+			name:        "invalid annotation",
+			annotations: map[string]string{pullerv1beta1.ExpiresAtAnnotationKey: "tomorrow"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "harbor", Namespace: "puller", Annotations: tt.annotations},
+				Type:       corev1.SecretTypeBasicAuth,
+				Data: map[string][]byte{
+					corev1.BasicAuthUsernameKey: []byte("user"),
+					corev1.BasicAuthPasswordKey: []byte("secret"),
+				},
+			}
+			puller := &pullerv1beta1.Puller{
+				ObjectMeta: metav1.ObjectMeta{Name: "puller"},
+				Spec: pullerv1beta1.PullerSpec{
+					Registries: []pullerv1beta1.Registry{{
+						Server: "harbor.corp",
+						Credentials: pullerv1beta1.RegistryCredentials{
+							SecretRef: &pullerv1beta1.SecretReference{Namespace: "puller", Name: "harbor"},
+						},
+						ExpiresAt: tt.expiresAt,
+					}},
+				},
+			}
+			resolved, err := newTestController(secret).resolveCredentials(context.Background(), puller)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveCredentials() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			r := resolved.Spec.Registries[0]
+			if b := r.Credentials.Basic; b == nil || b.Username != "user" || b.Password != "secret" {
+				t.Errorf("resolveCredentials() credentials = %+v, want user:secret", r.Credentials)
+			}
+			format := func(t *metav1.Time) string {
+				if t == nil {
+					return ""
+				}
+				return t.UTC().Format(time.RFC3339)
+			}
+			if got := format(r.ValidFrom); got != tt.wantValidFrom {
+				t.Errorf("resolveCredentials() validFrom = %q, want %q", got, tt.wantValidFrom)
+			}
+			if got := format(r.ExpiresAt); got != tt.wantExpiresAt {
+				t.Errorf("resolveCredentials() expiresAt = %q, want %q", got, tt.wantExpiresAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package puller
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+// distributionState records in memory the outcome of the last syncs of the
+// pullers, for the debug endpoint.
+type distributionState struct {
+	mu      sync.RWMutex
+	pullers map[string]*pullerState
+}
+
+type pullerState struct {
+	lastSync time.Time
+	// nextSync is when the last sync requeued the puller, zero if it did
+	// not.
+	nextSync   time.Time
+	namespaces map[string]namespaceState
+}
+
+type namespaceState struct {
+	lastSync time.Time
+	err      string
+}
+
+func (s *distributionState) get(name string) *pullerState {
+	if s.pullers == nil {
+		s.pullers = map[string]*pullerState{}
+	}
+	state, ok := s.pullers[name]
+	if !ok {
+		state = &pullerState{namespaces: map[string]namespaceState{}}
+		s.pullers[name] = state
+	}
+	return state
+}
+
+// recordNamespace records the outcome of the sync of a puller into a namespace.
+func (s *distributionState) recordNamespace(name, namespace string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := namespaceState{lastSync: time.Now()}
+	if err != nil {
+		state.err = err.Error()
+	}
+	s.get(name).namespaces[namespace] = state
+}
+
+// recordSync records a full sync of a puller, the namespaces it no longer
+// targets are forgotten.
+func (s *distributionState) recordSync(name string, namespaces []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.get(name)
+	state.lastSync = time.Now()
+	targeted := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		targeted[ns] = true
+	}
+	for ns := range state.namespaces {
+		if !targeted[ns] {
+			delete(state.namespaces, ns)
+		}
+	}
+}
+
+// recordRequeue records when the last sync of a puller requeued it.
+func (s *distributionState) recordRequeue(name string, after time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.get(name)
+	state.nextSync = time.Time{}
+	if after > 0 {
+		state.nextSync = time.Now().Add(after)
+	}
+}
+
+// forget drops the state of a puller that is gone.
+func (s *distributionState) forget(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pullers, name)
+}
+
+// snapshot returns a copy of the state of a puller.
+func (s *distributionState) snapshot(name string) pullerState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, ok := s.pullers[name]
+	if !ok {
+		return pullerState{}
+	}
+	namespaces := make(map[string]namespaceState, len(state.namespaces))
+	for ns, nsState := range state.namespaces {
+		namespaces[ns] = nsState
+	}
+	return pullerState{lastSync: state.lastSync, nextSync: state.nextSync, namespaces: namespaces}
+}
+
+// debugPuller is the distribution state of a puller served by the debug endpoint.
+type debugPuller struct {
+	Name      string `json:"name"`
+	Owned     bool   `json:"owned"`
+	Suspended bool   `json:"suspended,omitempty"`
+	Mode      string `json:"mode,omitempty"`
+	// Secret is the first output of the puller, the secret and the content
+	// hash of the namespaces are its own.
+	Secret string `json:"secret"`
+	// LastSyncTime is the last full sync by this replica.
+	LastSyncTime *time.Time `json:"lastSyncTime,omitempty"`
+	// NextRefreshTime is when the secrets are next rendered again from the
+	// puller: the requeue of the last sync, or the resync when it comes
+	// first, approximate since the resyncs are jittered.
+	NextRefreshTime *time.Time       `json:"nextRefreshTime,omitempty"`
+	Namespaces      []debugNamespace `json:"namespaces"`
+	Error           string           `json:"error,omitempty"`
+}
+
+type debugNamespace struct {
+	Name string `json:"name"`
+	// Secret is the name of the distributed secret, the current version of
+	// an immutable image pull secret.
+	Secret          string     `json:"secret,omitempty"`
+	ContentHash     string     `json:"contentHash,omitempty"`
+	ServiceAccounts []string   `json:"serviceAccounts,omitempty"`
+	LastSyncTime    *time.Time `json:"lastSyncTime,omitempty"`
+	LastError       string     `json:"lastError,omitempty"`
+}
+
+// DebugHandler serves the distribution state of every puller as JSON, or
+// of a single one with the name query parameter. It reads from the cache
+// and from the memory of this replica only.
+func (c *Controller) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		pullerList := pullerv1beta1.PullerList{}
+		if err := c.Client.List(ctx, &pullerList); err != nil {
+			http.Error(resp, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		nsList, err := c.listNamespaces(ctx)
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		name := req.URL.Query().Get("name")
+		pullers := []debugPuller{}
+		for i := range pullerList.Items {
+			puller := &pullerList.Items[i]
+			if name != "" && puller.Name != name {
+				continue
+			}
+			pullers = append(pullers, c.debugPuller(req, puller, nsList))
+		}
+		sort.Slice(pullers, func(i, j int) bool { return pullers[i].Name < pullers[j].Name })
+
+		resp.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(resp)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(pullers)
+	})
+}
+
+func (c *Controller) debugPuller(req *http.Request, puller *pullerv1beta1.Puller, nsList []corev1.Namespace) debugPuller {
+	ctx := req.Context()
+	state := c.state.snapshot(puller.Name)
+	output := pullerv1beta1.OutputsOf(puller)[0]
+	out := debugPuller{
+		Name:       puller.Name,
+		Owned:      c.owns(puller.Name),
+		Suspended:  puller.Spec.Suspend,
+		Mode:       string(puller.Spec.Mode),
+		Secret:     output.Name,
+		Namespaces: []debugNamespace{},
+	}
+	if !state.lastSync.IsZero() {
+		lastSync := state.lastSync
+		out.LastSyncTime = &lastSync
+		next := state.nextSync
+		if resync := lastSync.Add(c.ResyncPeriod); c.ResyncPeriod > 0 && (next.IsZero() || resync.Before(next)) {
+			next = resync
+		}
+		if !next.IsZero() {
+			out.NextRefreshTime = &next
+		}
+	}
+
+	for _, ns := range nsList {
+		ok, err := targetsNamespace(puller, &ns)
+		if err != nil {
+			out.Error = err.Error()
+			break
+		}
+		if !ok {
+			continue
+		}
+		item := debugNamespace{Name: ns.Name}
+		if nsState, ok := state.namespaces[ns.Name]; ok {
+			lastSync := nsState.lastSync
+			item.LastSyncTime = &lastSync
+			item.LastError = nsState.err
+		}
+		secret, err := c.distributedSecret(ctx, puller, ns.Name, output)
+		if err != nil || secret == nil {
+			out.Namespaces = append(out.Namespaces, item)
+			continue
+		}
+		item.Secret = secret.Name
+		item.ContentHash = secret.Annotations[ContentHashAnnotationKey]
+		saList := &corev1.ServiceAccountList{}
+		if err := c.Client.List(ctx, saList, client.InNamespace(ns.Name), client.MatchingFields{ManagedSecretsIndex: secret.Name}); err == nil {
+			for _, sa := range saList.Items {
+				item.ServiceAccounts = append(item.ServiceAccounts, sa.Name)
+			}
+			sort.Strings(item.ServiceAccounts)
+		}
+		out.Namespaces = append(out.Namespaces, item)
+	}
+	return out
+}
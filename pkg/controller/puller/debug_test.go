@@ -0,0 +1,130 @@
+package puller
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/scheme"
+)
+
+func TestDebugHandlerImmutableSecrets(t *testing.T) {
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller"},
+		Spec: pullerv1beta1.PullerSpec{
+			Rotation: &pullerv1beta1.Rotation{Strategy: pullerv1beta1.RotationStrategyImmutable},
+		},
+	}
+	now := time.Now()
+	version := func(name, hash string, created time.Time, superseded bool) *corev1.Secret {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(created),
+			Labels:            map[string]string{SecretLabelKey: puller.Name, OutputLabelKey: puller.Name},
+			Annotations:       map[string]string{ContentHashAnnotationKey: hash},
+		}}
+		if superseded {
+			secret.Annotations[SupersededAtAnnotationKey] = now.Format(time.RFC3339)
+		}
+		return secret
+	}
+	c := newTestController(
+		puller,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		version("puller-aaaaaaaa", "aaaa", now.Add(-time.Hour), true),
+		version("puller-bbbbbbbb", "bbbb", now.Add(-time.Minute), false),
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+			Name:        "default",
+			Namespace:   "default",
+			Annotations: map[string]string{ManagedSecretsAnnotationKey: "puller-bbbbbbbb"},
+		}},
+	)
+
+	pullers := serveDebug(t, c)
+	want := []debugNamespace{{
+		Name:            "default",
+		Secret:          "puller-bbbbbbbb",
+		ContentHash:     "bbbb",
+		ServiceAccounts: []string{"default"},
+	}}
+	if got := pullers[0].Namespaces; !reflect.DeepEqual(got, want) {
+		t.Errorf("namespaces = %+v, want %+v", got, want)
+	}
+}
+
+func TestDebugHandlerReadsCacheOnly(t *testing.T) {
+	puller := &pullerv1beta1.Puller{ObjectMeta: metav1.ObjectMeta{Name: "puller"}}
+	c := newTestController(puller, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+	// a secret puller does not manage is only seen by the API reader
+	c.APIReader = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "puller", Namespace: "default"}},
+	).Build()
+
+	pullers := serveDebug(t, c)
+	want := []debugNamespace{{Name: "default"}}
+	if got := pullers[0].Namespaces; !reflect.DeepEqual(got, want) {
+		t.Errorf("namespaces = %+v, want %+v", got, want)
+	}
+}
+
+func TestDebugHandlerNextRefreshTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		requeue time.Duration
+		resync  time.Duration
+		// want is the next refresh after the sync, zero for none
+		want time.Duration
+	}{
+		{name: "no requeue nor resync", want: 0},
+		{name: "requeue", requeue: time.Hour, want: time.Hour},
+		{name: "resync", resync: time.Hour, want: time.Hour},
+		{name: "requeue before the resync", requeue: time.Minute, resync: time.Hour, want: time.Minute},
+		{name: "resync before the requeue", requeue: time.Hour, resync: time.Minute, want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puller := &pullerv1beta1.Puller{ObjectMeta: metav1.ObjectMeta{Name: "puller"}}
+			c := newTestController(puller)
+			c.ResyncPeriod = tt.resync
+			c.state.recordSync(puller.Name, nil)
+			c.state.recordRequeue(puller.Name, tt.requeue)
+
+			got := serveDebug(t, c)[0]
+			if tt.want == 0 {
+				if got.NextRefreshTime != nil {
+					t.Errorf("nextRefreshTime = %v, want none", got.NextRefreshTime)
+				}
+				return
+			}
+			if got.NextRefreshTime == nil || got.LastSyncTime == nil {
+				t.Fatalf("nextRefreshTime = %v after %v, want one", got.NextRefreshTime, got.LastSyncTime)
+			}
+			if after := got.NextRefreshTime.Sub(*got.LastSyncTime); after < tt.want || after > tt.want+time.Second {
+				t.Errorf("nextRefreshTime is %v after the last sync, want %v", after, tt.want)
+			}
+		})
+	}
+}
+
+// serveDebug serves the debug endpoint for every puller and decodes it.
+func serveDebug(t *testing.T, c *Controller) []debugPuller {
+	t.Helper()
+	resp := httptest.NewRecorder()
+	c.DebugHandler().ServeHTTP(resp, httptest.NewRequest("GET", "/debug/puller", nil))
+	var pullers []debugPuller
+	if err := json.Unmarshal(resp.Body.Bytes(), &pullers); err != nil {
+		t.Fatalf("failed to decode %s: %v", resp.Body.String(), err)
+	}
+	if len(pullers) != 1 {
+		t.Fatalf("got %d pullers, want 1", len(pullers))
+	}
+	return pullers
+}
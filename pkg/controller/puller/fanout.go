@@ -0,0 +1,179 @@
+package puller
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+// checkpointInterval is the minimal interval between two checkpoints of the
+// progress of a puller.
+const checkpointInterval = 10 * time.Second
+
+// fanOutResult is the outcome of syncing or planning a single namespace.
+type fanOutResult struct {
+	changes []pullerv1beta1.PlannedChange
+	err     error
+}
+
+// fanOut calls fn for every namespace on at most FanOutWorkers workers, the
+// results are in the order of the namespaces. done, if not nil, is called
+// with each result as soon as it is known.
+func (c *Controller) fanOut(ctx context.Context, namespaces []string,
+	fn func(context.Context, string) ([]pullerv1beta1.PlannedChange, error),
+	done func(int, fanOutResult)) ([]fanOutResult, error) {
+	c.settingsMu.RLock()
+	workers := c.FanOutWorkers
+	c.settingsMu.RUnlock()
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]fanOutResult, len(namespaces))
+	workqueue.ParallelizeUntil(ctx, workers, len(namespaces), func(i int) {
+		changes, err := fn(ctx, namespaces[i])
+		results[i] = fanOutResult{changes: changes, err: err}
+		fanOutNamespaces.WithLabelValues(pullerQueue).Inc()
+		if done != nil {
+			done(i, results[i])
+		}
+	})
+	// the namespaces left when the context is done have no result
+	return results, ctx.Err()
+}
+
+// SetFanOutWorkers changes the number of namespaces a puller syncs in
+// parallel, the fan-outs in progress keep their number of workers.
+func (c *Controller) SetFanOutWorkers(workers int) {
+	c.settingsMu.Lock()
+	defer c.settingsMu.Unlock()
+	c.FanOutWorkers = workers
+}
+
+// waitForWrite blocks until the write rate limit allows one more write.
+func (c *Controller) waitForWrite(ctx context.Context) error {
+	if c.WriteLimiter == nil {
+		return nil
+	}
+	return c.WriteLimiter.Wait(ctx)
+}
+
+// progressTracker follows the namespaces synced by a fan-out and checkpoints
+// them in the status of the puller. Namespaces are synced in lexical order,
+// the checkpoint is the last namespace up to which all of them are synced.
+type progressTracker struct {
+	c      *Controller
+	puller *pullerv1beta1.Puller
+	// namespaces are the sorted namespaces the fan-out runs on.
+	namespaces []string
+
+	mu             sync.Mutex
+	progress       pullerv1beta1.Progress
+	results        []*fanOutResult
+	next           int
+	lastCheckpoint time.Time
+}
+
+// resumeProgress drops the target namespaces that were synced before the
+// checkpoint of the puller, and returns a tracker for the remaining ones.
+// The checkpoint is ignored when the puller or the content of its secrets,
+// identified by hash, changed since it was taken.
+func (c *Controller) resumeProgress(puller *pullerv1beta1.Puller, hash string, namespaces []string) *progressTracker {
+	sort.Strings(namespaces)
+	progress := pullerv1beta1.Progress{
+		ObservedGeneration: puller.Generation,
+		ContentHash:        hash,
+		Total:              len(namespaces),
+	}
+	remaining := namespaces
+	if got := puller.Status.Progress; got != nil && got.ObservedGeneration == puller.Generation &&
+		got.ContentHash == hash && got.Namespace != "" {
+		i := sort.SearchStrings(namespaces, got.Namespace)
+		if i < len(namespaces) && namespaces[i] == got.Namespace {
+			i++
+		}
+		remaining = namespaces[i:]
+		progress.Namespace = got.Namespace
+		progress.Synced = i
+		for _, ns := range got.Blocked {
+			j := sort.SearchStrings(namespaces[:i], ns)
+			if j < i && namespaces[j] == ns {
+				progress.Blocked = append(progress.Blocked, ns)
+			}
+		}
+	}
+	return &progressTracker{
+		c:              c,
+		puller:         puller.DeepCopy(),
+		namespaces:     remaining,
+		progress:       progress,
+		results:        make([]*fanOutResult, len(remaining)),
+		lastCheckpoint: time.Now(),
+	}
+}
+
+// done records the result of a namespace, and checkpoints the progress if the
+// last checkpoint is old enough.
+func (t *progressTracker) done(ctx context.Context, i int, result fanOutResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.results[i] = &result
+	advanced := false
+	for t.next < len(t.results) && t.results[t.next] != nil {
+		err := t.results[t.next].err
+		var conflictErr *secretConflictError
+		if errors.As(err, &conflictErr) {
+			t.progress.Blocked = append(t.progress.Blocked, t.namespaces[t.next])
+		} else if err != nil {
+			// the namespace has to be synced again after a restart
+			break
+		}
+		t.progress.Namespace = t.namespaces[t.next]
+		t.progress.Synced++
+		t.next++
+		advanced = true
+	}
+	if !advanced || time.Since(t.lastCheckpoint) < checkpointInterval {
+		return
+	}
+	patched, err := t.c.checkpoint(ctx, t.puller, t.snapshot())
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to checkpoint puller progress", "name", t.puller.Name)
+		return
+	}
+	t.puller = patched
+	t.lastCheckpoint = time.Now()
+}
+
+// snapshot returns a copy of the progress recorded so far.
+func (t *progressTracker) snapshot() *pullerv1beta1.Progress {
+	return t.progress.DeepCopy()
+}
+
+// observe copies the last checkpoint into the puller, so that the final
+// status update is compared against, and sent on top of, the stored puller.
+func (t *progressTracker) observe(puller *pullerv1beta1.Puller) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	puller.ResourceVersion = t.puller.ResourceVersion
+	puller.Status.Progress = t.puller.Status.Progress
+}
+
+// checkpoint patches the progress into the status of the puller, without
+// touching the rest of the status.
+func (c *Controller) checkpoint(ctx context.Context, puller *pullerv1beta1.Puller, progress *pullerv1beta1.Progress) (*pullerv1beta1.Puller, error) {
+	patched := puller.DeepCopy()
+	patched.Status.Progress = progress
+	if err := c.Client.Status().Patch(ctx, patched, client.MergeFrom(puller)); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
@@ -0,0 +1,197 @@
+package puller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+func TestFanOutWorkers(t *testing.T) {
+	const workers = 3
+	c := newTestController()
+	c.SetFanOutWorkers(workers)
+	namespaces := []string{"a", "b", "c", "d", "e", "f", "g"}
+
+	// the first calls only return once as many as the workers run together
+	var (
+		mu      sync.Mutex
+		running int
+		most    int
+	)
+	started := make(chan struct{})
+	var once sync.Once
+	fn := func(ctx context.Context, ns string) ([]pullerv1beta1.PlannedChange, error) {
+		mu.Lock()
+		running++
+		if running > most {
+			most = running
+		}
+		if running == workers {
+			once.Do(func() { close(started) })
+		}
+		mu.Unlock()
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+		}
+		mu.Lock()
+		running--
+		mu.Unlock()
+		if ns == "c" {
+			return nil, errors.New("failed")
+		}
+		return []pullerv1beta1.PlannedChange{{Namespace: ns}}, nil
+	}
+	results, err := c.fanOut(context.Background(), namespaces, fn, nil)
+	if err != nil {
+		t.Fatalf("fanOut() error = %v", err)
+	}
+	if most != workers {
+		t.Errorf("ran %d namespaces together, want %d", most, workers)
+	}
+	for i, result := range results {
+		if namespaces[i] == "c" {
+			if result.err == nil {
+				t.Errorf("result of %s has no error", namespaces[i])
+			}
+			continue
+		}
+		if len(result.changes) != 1 || result.changes[0].Namespace != namespaces[i] {
+			t.Errorf("result %d = %+v, want the one of %s", i, result, namespaces[i])
+		}
+	}
+}
+
+func TestWaitForWrite(t *testing.T) {
+	c := newTestController()
+	c.WriteLimiter = rate.NewLimiter(rate.Every(50*time.Millisecond), 1)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := c.waitForWrite(ctx); err != nil {
+			t.Fatalf("waitForWrite() error = %v", err)
+		}
+	}
+	// the burst allows the first write at once
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 writes took %v, want at least 100ms", elapsed)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := c.waitForWrite(canceled); err == nil {
+		t.Error("waitForWrite() with a canceled context returned no error")
+	}
+}
+
+func TestResumeProgress(t *testing.T) {
+	namespaces := []string{"d", "a", "c", "b", "e"}
+	checkpoint := &pullerv1beta1.Progress{
+		ObservedGeneration: 2,
+		ContentHash:        "hash",
+		Namespace:          "b",
+		Synced:             2,
+		Total:              5,
+		Blocked:            []string{"a", "gone"},
+	}
+	tests := []struct {
+		name       string
+		progress   *pullerv1beta1.Progress
+		generation int64
+		hash       string
+		want       []string
+		wantSynced int
+		wantBlock  []string
+	}{
+		{name: "no checkpoint", generation: 2, hash: "hash", want: []string{"a", "b", "c", "d", "e"}},
+		{name: "checkpoint", progress: checkpoint, generation: 2, hash: "hash", want: []string{"c", "d", "e"}, wantSynced: 2, wantBlock: []string{"a"}},
+		{name: "new generation", progress: checkpoint, generation: 3, hash: "hash", want: []string{"a", "b", "c", "d", "e"}},
+		{name: "new content", progress: checkpoint, generation: 2, hash: "rotated", want: []string{"a", "b", "c", "d", "e"}},
+		{
+			name: "checkpoint namespace deleted",
+			progress: &pullerv1beta1.Progress{
+				ObservedGeneration: 2,
+				ContentHash:        "hash",
+				Namespace:          "bb",
+			},
+			generation: 2, hash: "hash", want: []string{"c", "d", "e"}, wantSynced: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puller := &pullerv1beta1.Puller{
+				ObjectMeta: metav1.ObjectMeta{Name: "puller", Generation: tt.generation},
+				Status:     pullerv1beta1.PullerStatus{Progress: tt.progress},
+			}
+			tracker := newTestController().resumeProgress(puller, tt.hash, append([]string(nil), namespaces...))
+			if !reflect.DeepEqual(tracker.namespaces, tt.want) {
+				t.Errorf("namespaces = %v, want %v", tracker.namespaces, tt.want)
+			}
+			got := tracker.snapshot()
+			if got.Synced != tt.wantSynced || got.Total != len(namespaces) || !reflect.DeepEqual(got.Blocked, tt.wantBlock) {
+				t.Errorf("progress = %+v, want %d of %d synced, blocked %v", got, tt.wantSynced, len(namespaces), tt.wantBlock)
+			}
+			if got.ObservedGeneration != tt.generation || got.ContentHash != tt.hash {
+				t.Errorf("progress of generation %d and content %q, want %d and %q", got.ObservedGeneration, got.ContentHash, tt.generation, tt.hash)
+			}
+		})
+	}
+}
+
+func TestProgressTrackerCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	puller := &pullerv1beta1.Puller{ObjectMeta: metav1.ObjectMeta{Name: "puller", Generation: 1}}
+	c := newTestController(puller)
+	tracker := c.resumeProgress(puller, "hash", []string{"a", "b", "c", "d"})
+
+	steps := []struct {
+		i      int
+		err    error
+		want   string
+		synced int
+	}{
+		// b is done before a, the checkpoint waits for a
+		{i: 1, want: "", synced: 0},
+		{i: 0, want: "b", synced: 2},
+		// c failed, it is synced again on resume
+		{i: 2, err: errors.New("failed"), want: "b", synced: 2},
+		{i: 3, want: "b", synced: 2},
+	}
+	for _, step := range steps {
+		// checkpoint on every advance
+		tracker.lastCheckpoint = time.Time{}
+		tracker.done(ctx, step.i, fanOutResult{err: step.err})
+		got := &pullerv1beta1.Puller{}
+		if err := c.Client.Get(ctx, client.ObjectKeyFromObject(puller), got); err != nil {
+			t.Fatal(err)
+		}
+		var namespace string
+		var synced int
+		if p := got.Status.Progress; p != nil {
+			namespace, synced = p.Namespace, p.Synced
+		}
+		if namespace != step.want || synced != step.synced {
+			t.Errorf("after %s: checkpoint at %q with %d synced, want %q with %d", tracker.namespaces[step.i], namespace, synced, step.want, step.synced)
+		}
+	}
+
+	// the restarted sync resumes from the checkpoint
+	got := &pullerv1beta1.Puller{}
+	if err := c.Client.Get(ctx, client.ObjectKeyFromObject(puller), got); err != nil {
+		t.Fatal(err)
+	}
+	resumed := c.resumeProgress(got, "hash", []string{"a", "b", "c", "d"})
+	if want := []string{"c", "d"}; !reflect.DeepEqual(resumed.namespaces, want) {
+		t.Errorf("resumed on %v, want %v", resumed.namespaces, want)
+	}
+}
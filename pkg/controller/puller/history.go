@@ -0,0 +1,385 @@
+package puller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/registry"
+)
+
+// errHistoryDisabled is returned for a rollback without revision history.
+var errHistoryDisabled = errors.New("the revision history is disabled")
+
+// revision is a revision of the credentials of a puller.
+type revision struct {
+	number int64
+	secret *corev1.Secret
+}
+
+// recordedRevisions remembers the revision last recorded for each puller, so
+// that its history is only listed again when its credentials or its history
+// limit change.
+type recordedRevisions struct {
+	mu      sync.Mutex
+	pullers map[string]recordedRevision
+}
+
+// recordedRevision is the revision last recorded for a puller.
+type recordedRevision struct {
+	uid    types.UID
+	hash   string
+	limit  int
+	number int64
+}
+
+// lookup returns the revision recorded for the credentials of the puller
+// with the given hash, if they are the last recorded ones.
+func (r *recordedRevisions) lookup(puller *pullerv1beta1.Puller, hash string) (int64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last, ok := r.pullers[puller.Name]
+	if !ok || last.uid != puller.UID || last.hash != hash || last.limit != pullerv1beta1.RevisionHistoryLimit(puller) {
+		return 0, false
+	}
+	return last.number, true
+}
+
+// record records the revision of the credentials of the puller with the
+// given hash.
+func (r *recordedRevisions) record(puller *pullerv1beta1.Puller, hash string, number int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pullers == nil {
+		r.pullers = map[string]recordedRevision{}
+	}
+	r.pullers[puller.Name] = recordedRevision{
+		uid:    puller.UID,
+		hash:   hash,
+		limit:  pullerv1beta1.RevisionHistoryLimit(puller),
+		number: number,
+	}
+}
+
+// forget drops the revision of a puller that is gone.
+func (r *recordedRevisions) forget(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pullers, name)
+}
+
+// revisionSecretName names the secret of a revision of the credentials of
+// the puller after its name and the prefix of its UID, so that the history of
+// a puller recreated under the same name does not collide with the previous
+// one. The name of the puller is truncated to fit.
+func revisionSecretName(puller *pullerv1beta1.Puller, number int64) string {
+	uid := string(puller.UID)
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+	suffix := "-" + uid + "-" + strconv.FormatInt(number, 10)
+	name := puller.Name
+	if n := validation.DNS1123SubdomainMaxLength - len(suffix); len(name) > n {
+		name = name[:n]
+	}
+	return name + suffix
+}
+
+// renderPuller returns a copy of the puller whose registries hold the
+// credentials it distributes: the credentials of the revision it is rolled
+// back to, or its own with the ones referenced from Secrets resolved.
+func (c *Controller) renderPuller(ctx context.Context, puller *pullerv1beta1.Puller) (*pullerv1beta1.Puller, error) {
+	if puller.Spec.RollbackTo == nil {
+		return c.resolveCredentials(ctx, puller)
+	}
+	// the referenced secrets are not read, the bad edit rolled back may
+	// reference a missing one
+	number := *puller.Spec.RollbackTo
+	if c.HistoryNamespace == "" {
+		return nil, errHistoryDisabled
+	}
+	revisions, err := c.listRevisions(ctx, puller)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range revisions {
+		if r.number != number {
+			continue
+		}
+		registries, err := registriesOfRevision(r.secret)
+		if err != nil {
+			return nil, fmt.Errorf("revision %d: %w", number, err)
+		}
+		rendered := puller.DeepCopy()
+		rendered.Spec.Registries = registries
+		return rendered, nil
+	}
+	return nil, fmt.Errorf("revision %d not found in the history of the puller", number)
+}
+
+// listRevisions returns the revisions of the credentials of the puller,
+// from the oldest to the newest. They are not cached, they are listed on a
+// rollback and when the credentials of the puller change.
+func (c *Controller) listRevisions(ctx context.Context, puller *pullerv1beta1.Puller) ([]revision, error) {
+	secretList := &corev1.SecretList{}
+	if err := c.APIReader.List(ctx, secretList, client.InNamespace(c.HistoryNamespace),
+		client.MatchingLabels{HistoryLabelKey: string(puller.UID)}); err != nil {
+		return nil, err
+	}
+	revisions := make([]revision, 0, len(secretList.Items))
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+		number, err := strconv.ParseInt(secret.Labels[RevisionLabelKey], 10, 64)
+		if err != nil {
+			continue
+		}
+		revisions = append(revisions, revision{number: number, secret: secret})
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].number < revisions[j].number
+	})
+	return revisions, nil
+}
+
+// recordRevision returns the revision of the rendered credentials, a new one
+// when no revision holds them yet, and prunes the revisions over the history
+// limit of the puller. The history is not listed again while the rendered
+// credentials are the last recorded ones.
+func (c *Controller) recordRevision(ctx context.Context, puller, rendered *pullerv1beta1.Puller) (int64, error) {
+	if c.HistoryNamespace == "" {
+		return 0, nil
+	}
+	data, err := buildDockerConfigJSON(rendered.Spec.Registries)
+	if err != nil {
+		return 0, err
+	}
+	servers, err := json.Marshal(serversOf(rendered.Spec.Registries))
+	if err != nil {
+		return 0, err
+	}
+	validity, err := validityAnnotationOf(rendered.Spec.Registries)
+	if err != nil {
+		return 0, err
+	}
+	sum := sha256.Sum256(append(data, validity...))
+	hash := hex.EncodeToString(sum[:])
+	if number, ok := c.revisions.lookup(puller, hash); ok {
+		return number, nil
+	}
+	// a revision recorded concurrently under the same number is listed
+	// again, it may hold the same credentials
+	var (
+		revisions []revision
+		current   int64
+		created   bool
+	)
+	err = retry.OnError(retry.DefaultRetry, apierrors.IsAlreadyExists, func() error {
+		var err error
+		if revisions, err = c.listRevisions(ctx, puller); err != nil {
+			return err
+		}
+		current = 0
+		for _, r := range revisions {
+			// a rollback distributes the credentials within their window
+			if bytes.Equal(r.secret.Data[corev1.DockerConfigJsonKey], data) &&
+				r.secret.Annotations[RevisionValidityAnnotationKey] == validity {
+				current = r.number
+			}
+		}
+		if current != 0 {
+			return nil
+		}
+		current = 1
+		if n := len(revisions); n != 0 {
+			current = revisions[n-1].number + 1
+		}
+		immutable := true
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      revisionSecretName(puller, current),
+				Namespace: c.HistoryNamespace,
+				Labels: map[string]string{
+					HistoryLabelKey:  string(puller.UID),
+					RevisionLabelKey: strconv.FormatInt(current, 10),
+				},
+				Annotations: map[string]string{
+					RevisionServersAnnotationKey: string(servers),
+				},
+			},
+			Type:      corev1.SecretTypeDockerConfigJson,
+			Immutable: &immutable,
+			Data:      map[string][]byte{corev1.DockerConfigJsonKey: data},
+		}
+		if validity != "" {
+			secret.Annotations[RevisionValidityAnnotationKey] = validity
+		}
+		// the history is garbage collected with the puller
+		if err := controllerutil.SetOwnerReference(puller, secret, c.Scheme); err != nil {
+			return err
+		}
+		_, err = c.KubeClient.CoreV1().Secrets(c.HistoryNamespace).Create(ctx, secret, metav1.CreateOptions{
+			FieldManager: FieldManager,
+		})
+		created = err == nil
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		// the history namespace does not exist, the history is disabled
+		// until the credentials change
+		c.historyMissing.Do(func() {
+			log.FromContext(ctx).Info("The history namespace does not exist, the revisions of the credentials are not recorded", "namespace", c.HistoryNamespace)
+		})
+		c.revisions.record(puller, hash, 0)
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if created {
+		log.FromContext(ctx).Info("Recorded a revision of the credentials", "name", puller.Name, "revision", current)
+	}
+
+	// the distributed revision is kept besides the limit
+	var errs []error
+	previous := len(revisions)
+	for _, r := range revisions {
+		if r.number == current {
+			previous--
+		}
+	}
+	limit := pullerv1beta1.RevisionHistoryLimit(puller)
+	for _, r := range revisions {
+		if previous <= limit {
+			break
+		}
+		if r.number == current {
+			continue
+		}
+		err := c.KubeClient.CoreV1().Secrets(r.secret.Namespace).Delete(ctx, r.secret.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &r.secret.UID},
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+		previous--
+	}
+	if len(errs) != 0 {
+		return current, utilerrors.NewAggregate(errs)
+	}
+	c.revisions.record(puller, hash, current)
+	return current, nil
+}
+
+// serversOf maps the docker config keys of the registries to their servers,
+// so that a revision restores the servers as written in the spec.
+func serversOf(registries []pullerv1beta1.Registry) map[string]string {
+	servers := make(map[string]string, len(registries))
+	for _, r := range registries {
+		servers[registry.ConfigKey(r.Server)] = r.Server
+	}
+	return servers
+}
+
+// revisionValidity is the validity window set in the spec for a registry.
+type revisionValidity struct {
+	ValidFrom *metav1.Time `json:"validFrom,omitempty"`
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+// validityAnnotationOf maps the docker config keys of the registries to the
+// validity window set in their spec, empty if none has one. The window of a
+// JWT credential is read again from its claims.
+func validityAnnotationOf(registries []pullerv1beta1.Registry) (string, error) {
+	validity := map[string]revisionValidity{}
+	for _, r := range registries {
+		if r.ValidFrom == nil && r.ExpiresAt == nil {
+			continue
+		}
+		validity[registry.ConfigKey(r.Server)] = revisionValidity{ValidFrom: r.ValidFrom, ExpiresAt: r.ExpiresAt}
+	}
+	if len(validity) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(validity)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// registriesOfRevision reads the registries, their credentials and their
+// validity window from the docker config and the annotations of a revision.
+func registriesOfRevision(secret *corev1.Secret) ([]pullerv1beta1.Registry, error) {
+	config := struct {
+		Auths map[string]dockerConfigEntry `json:"auths"`
+	}{}
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+		return nil, fmt.Errorf("invalid docker config: %w", err)
+	}
+	servers := map[string]string{}
+	if data, ok := secret.Annotations[RevisionServersAnnotationKey]; ok {
+		if err := json.Unmarshal([]byte(data), &servers); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %w", RevisionServersAnnotationKey, err)
+		}
+	}
+
+	validity := map[string]revisionValidity{}
+	if data, ok := secret.Annotations[RevisionValidityAnnotationKey]; ok {
+		if err := json.Unmarshal([]byte(data), &validity); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %w", RevisionValidityAnnotationKey, err)
+		}
+	}
+
+	keys := make([]string, 0, len(config.Auths))
+	for key := range config.Auths {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return registry.MoreSpecific(keys[i], keys[j])
+	})
+	registries := make([]pullerv1beta1.Registry, 0, len(keys))
+	for _, key := range keys {
+		entry := config.Auths[key]
+		r := pullerv1beta1.Registry{Server: key}
+		if server, ok := servers[key]; ok {
+			r.Server = server
+		}
+		if v, ok := validity[key]; ok {
+			r.ValidFrom, r.ExpiresAt = v.ValidFrom, v.ExpiresAt
+		}
+		if entry.IdentityToken != "" || entry.RegistryToken != "" {
+			r.Credentials.Token = &pullerv1beta1.TokenCredentials{
+				Username:      entry.Username,
+				IdentityToken: entry.IdentityToken,
+				RegistryToken: entry.RegistryToken,
+			}
+		} else {
+			r.Credentials.Basic = &pullerv1beta1.BasicCredentials{
+				Username: entry.Username,
+				Password: entry.Password,
+				Auth:     entry.Auth,
+				Email:    entry.Email,
+			}
+		}
+		registries = append(registries, r)
+	}
+	return registries, nil
+}
@@ -0,0 +1,251 @@
+package puller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+// historyReader serves the lists of secrets from the clientset the
+// controller writes to, and counts them.
+type historyReader struct {
+	kube  kubernetes.Interface
+	lists int
+}
+
+func (r *historyReader) Get(context.Context, client.ObjectKey, client.Object, ...client.GetOption) error {
+	return errors.New("not implemented")
+}
+
+func (r *historyReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	r.lists++
+	o := &client.ListOptions{}
+	o.ApplyOptions(opts)
+	got, err := r.kube.CoreV1().Secrets(o.Namespace).List(ctx, metav1.ListOptions{LabelSelector: o.LabelSelector.String()})
+	if err != nil {
+		return err
+	}
+	*list.(*corev1.SecretList) = *got
+	return nil
+}
+
+func TestRecordRevision(t *testing.T) {
+	ctx := context.Background()
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller", UID: "uid"},
+		Spec: pullerv1beta1.PullerSpec{
+			Registries: []pullerv1beta1.Registry{{
+				Server: "harbor.corp",
+				Credentials: pullerv1beta1.RegistryCredentials{
+					Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "secret"},
+				},
+			}},
+		},
+	}
+	c := newTestController()
+	c.HistoryNamespace = "puller"
+	reader := &historyReader{kube: c.KubeClient}
+	c.APIReader = reader
+
+	rotated := puller.DeepCopy()
+	rotated.Spec.Registries[0].Credentials.Basic.Password = "rotated"
+	limited := rotated.DeepCopy()
+	limit := int32(0)
+	limited.Spec.RevisionHistoryLimit = &limit
+
+	steps := []struct {
+		name      string
+		puller    *pullerv1beta1.Puller
+		want      int64
+		wantLists int
+	}{
+		{name: "first credentials are recorded", puller: puller, want: 1, wantLists: 1},
+		{name: "same credentials are not listed again", puller: puller, want: 1, wantLists: 1},
+		{name: "rotated credentials are recorded", puller: rotated, want: 2, wantLists: 2},
+		{name: "rotated credentials are not listed again", puller: rotated, want: 2, wantLists: 2},
+		{name: "a new history limit prunes", puller: limited, want: 2, wantLists: 3},
+		{name: "pruned credentials are recorded again", puller: puller, want: 3, wantLists: 4},
+	}
+	for _, step := range steps {
+		got, err := c.recordRevision(ctx, step.puller, step.puller)
+		if err != nil {
+			t.Fatalf("%s: recordRevision() error = %v", step.name, err)
+		}
+		if got != step.want || reader.lists != step.wantLists {
+			t.Errorf("%s: recordRevision() = %d after %d lists, want %d after %d lists", step.name, got, reader.lists, step.want, step.wantLists)
+		}
+	}
+
+	secrets, err := c.KubeClient.CoreV1().Secrets("puller").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the revisions 2 and 3, the first one was pruned
+	if n := len(secrets.Items); n != 2 {
+		t.Errorf("recorded %d revisions, want 2", n)
+	}
+}
+
+func TestRecordRevisionMissingNamespace(t *testing.T) {
+	ctx := context.Background()
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller", UID: "uid"},
+		Spec: pullerv1beta1.PullerSpec{
+			Registries: []pullerv1beta1.Registry{{
+				Server: "harbor.corp",
+				Credentials: pullerv1beta1.RegistryCredentials{
+					Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "secret"},
+				},
+			}},
+		},
+	}
+	c := newTestController()
+	c.HistoryNamespace = "missing"
+	reader := &historyReader{kube: c.KubeClient}
+	c.APIReader = reader
+	c.KubeClient.(*kubefake.Clientset).PrependReactor("create", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(corev1.Resource("namespaces"), "missing")
+	})
+
+	for i := 0; i < 2; i++ {
+		got, err := c.recordRevision(ctx, puller, puller)
+		if err != nil || got != 0 {
+			t.Fatalf("recordRevision() = %d, %v, want the history disabled", got, err)
+		}
+	}
+	if reader.lists != 1 {
+		t.Errorf("listed the history %d times, want once", reader.lists)
+	}
+}
+
+func TestRecordRevisionRecreatedPuller(t *testing.T) {
+	ctx := context.Background()
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller", UID: "0b7c2f6e-old"},
+		Spec: pullerv1beta1.PullerSpec{
+			Registries: []pullerv1beta1.Registry{{
+				Server: "harbor.corp",
+				Credentials: pullerv1beta1.RegistryCredentials{
+					Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "secret"},
+				},
+			}},
+		},
+	}
+	// the history of the deleted puller is not garbage collected yet
+	recreated := puller.DeepCopy()
+	recreated.UID = "5d1e9a04-new"
+	c := newTestController()
+	c.HistoryNamespace = "puller"
+	c.APIReader = &historyReader{kube: c.KubeClient}
+
+	for _, p := range []*pullerv1beta1.Puller{puller, recreated} {
+		got, err := c.recordRevision(ctx, p, p)
+		if err != nil || got != 1 {
+			t.Fatalf("recordRevision(%s) = %d, %v, want revision 1", p.UID, got, err)
+		}
+	}
+	for _, name := range []string{"puller-0b7c2f6e-1", "puller-5d1e9a04-1"} {
+		if _, err := c.KubeClient.CoreV1().Secrets("puller").Get(ctx, name, metav1.GetOptions{}); err != nil {
+			t.Errorf("revision %s not recorded, error = %v", name, err)
+		}
+	}
+}
+
+func TestRecordRevisionConcurrent(t *testing.T) {
+	ctx := context.Background()
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller", UID: "uid"},
+		Spec: pullerv1beta1.PullerSpec{
+			Registries: []pullerv1beta1.Registry{{
+				Server: "harbor.corp",
+				Credentials: pullerv1beta1.RegistryCredentials{
+					Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "secret"},
+				},
+			}},
+		},
+	}
+	c := newTestController()
+	c.HistoryNamespace = "puller"
+	reader := &historyReader{kube: c.KubeClient}
+	c.APIReader = reader
+	// another controller records the same revision between the list and
+	// the create
+	clientset := c.KubeClient.(*kubefake.Clientset)
+	creates := 0
+	clientset.PrependReactor("create", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		creates++
+		secret := action.(clienttesting.CreateAction).GetObject().(*corev1.Secret)
+		if err := clientset.Tracker().Add(secret); err != nil {
+			return true, nil, err
+		}
+		return true, nil, apierrors.NewAlreadyExists(corev1.Resource("secrets"), secret.Name)
+	})
+
+	got, err := c.recordRevision(ctx, puller, puller)
+	if err != nil || got != 1 {
+		t.Fatalf("recordRevision() = %d, %v, want revision 1", got, err)
+	}
+	if creates != 1 || reader.lists != 2 {
+		t.Errorf("created %d times after %d lists, want the history listed again instead of a second create", creates, reader.lists)
+	}
+}
+
+func TestRollbackValidity(t *testing.T) {
+	ctx := context.Background()
+	expiresAt := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller", UID: "uid"},
+		Spec: pullerv1beta1.PullerSpec{
+			Registries: []pullerv1beta1.Registry{{
+				Server: "harbor.corp",
+				Credentials: pullerv1beta1.RegistryCredentials{
+					Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "secret"},
+				},
+				ExpiresAt: &expiresAt,
+			}},
+		},
+	}
+	c := newTestController()
+	c.HistoryNamespace = "puller"
+	c.APIReader = &historyReader{kube: c.KubeClient}
+	if _, err := c.recordRevision(ctx, puller, puller); err != nil {
+		t.Fatalf("recordRevision() error = %v", err)
+	}
+
+	// the same credentials without their window are another revision
+	open := puller.DeepCopy()
+	open.Spec.Registries[0].ExpiresAt = nil
+	if got, err := c.recordRevision(ctx, open, open); err != nil || got != 2 {
+		t.Fatalf("recordRevision() = %d, %v, want revision 2", got, err)
+	}
+
+	rollback := open.DeepCopy()
+	number := int64(1)
+	rollback.Spec.RollbackTo = &number
+	rendered, err := c.renderPuller(ctx, rollback)
+	if err != nil {
+		t.Fatalf("renderPuller() error = %v", err)
+	}
+	if got := rendered.Spec.Registries[0].ExpiresAt; got == nil || !got.Equal(&expiresAt) {
+		t.Fatalf("rolled back expiresAt = %v, want %v", got, expiresAt)
+	}
+	valid, statuses, _ := checkValidity(rollback, rendered, time.Now())
+	if len(valid.Spec.Registries) != 0 {
+		t.Errorf("checkValidity() kept %d registries, want the expired credentials dropped", len(valid.Spec.Registries))
+	}
+	if len(statuses) != 1 || statuses[0].State != pullerv1beta1.CredentialsStateExpired {
+		t.Errorf("checkValidity() statuses = %+v, want expired", statuses)
+	}
+}
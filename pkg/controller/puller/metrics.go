@@ -0,0 +1,40 @@
+package puller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	orphanActionDeleted  = "deleted"
+	orphanActionReported = "reported"
+
+	// pullerQueue is the name of the workqueue of the puller reconciles.
+	pullerQueue = "puller"
+
+	// FanOutNamespacesMetric counts the namespaces synced by the fan-out of
+	// the puller reconciles, by workqueue. The liveness check sees them as
+	// progress of the workqueue of the pullers.
+	FanOutNamespacesMetric = "puller_fanout_namespaces_total"
+)
+
+var (
+	orphanSecrets = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "puller_orphan_secrets_total",
+		Help: "Number of orphaned secrets found by the sweeper, partitioned by action.",
+	}, []string{"action"})
+
+	orphanServiceAccountRefs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "puller_orphan_service_account_refs_total",
+		Help: "Number of orphaned service account image pull secret references found by the sweeper, partitioned by action.",
+	}, []string{"action"})
+
+	fanOutNamespaces = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: FanOutNamespacesMetric,
+		Help: "Number of namespaces synced or planned by the puller reconciles, partitioned by workqueue.",
+	}, []string{"name"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(orphanSecrets, orphanServiceAccountRefs, fanOutNamespaces)
+}
@@ -0,0 +1,200 @@
+package puller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/apis/puller/validation"
+	"github.com/puller-io/puller/pkg/tracing"
+)
+
+// namespaceRequest builds the work item syncing a puller into a namespace.
+// Pullers are cluster scoped, so the namespace of the request carries the
+// target namespace.
+func namespaceRequest(puller, namespace string) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{
+		Name:      puller,
+		Namespace: namespace,
+	}}
+}
+
+// ReconcileNamespace syncs a single puller into a single namespace.
+func (c *Controller) ReconcileNamespace(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "ReconcileNamespace",
+		attribute.String("puller", req.Name), attribute.String("namespace", req.Namespace))
+	result, err := c.reconcileNamespace(ctx, req)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (c *Controller) reconcileNamespace(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.V(4).Info("Reconciling puller in namespace", "name", req.Name, "namespace", req.Namespace)
+
+	if !c.owns(req.Name) {
+		return ctrl.Result{}, nil
+	}
+
+	puller := &pullerv1beta1.Puller{}
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: req.Name}, puller); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	// the puller reconcile owns the finalizer, the deletion, the plan, the
+	// suspension and the invalid specs, a puller in any of these states is
+	// left to it.
+	if !puller.DeletionTimestamp.IsZero() || !controllerutil.ContainsFinalizer(puller, FinalizerKey) ||
+		puller.Spec.Suspend || c.DryRun || puller.Spec.Mode == pullerv1beta1.ModePlan ||
+		len(validation.ValidatePuller(puller)) != 0 {
+		return ctrl.Result{}, nil
+	}
+
+	ns, err := c.getNamespace(ctx, req.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if ns == nil || !ns.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	if ok, err := targetsNamespace(puller, ns); err != nil || !ok {
+		return ctrl.Result{}, err
+	}
+
+	rendered, err := c.renderPuller(ctx, puller)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	rendered, _ = withoutMissingCredentials(rendered)
+	rendered, _, _ = checkValidity(puller, rendered, time.Now())
+	// the stages of a rollout in progress are synced by the puller reconcile
+	if ok, err := c.rolledOut(puller, rendered); err != nil || !ok {
+		return ctrl.Result{}, err
+	}
+	// the namespace may be new to the puller, created or labelled since
+	// its last sync, the counts of a rollout in progress are left to the
+	// puller reconcile
+	if err := c.updateTargetNamespaces(ctx, puller); err != nil {
+		return ctrl.Result{}, err
+	}
+	err = c.syncNamespace(ctx, rendered, ns.Name)
+	var conflictErr *secretConflictError
+	if errors.As(err, &conflictErr) {
+		c.EventRecorder.Event(puller, corev1.EventTypeWarning, "SecretConflict", err.Error())
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{}, err
+}
+
+// updateTargetNamespaces counts the target namespaces of the puller into its
+// status and the total of its progress, without touching the rest of the
+// status.
+func (c *Controller) updateTargetNamespaces(ctx context.Context, puller *pullerv1beta1.Puller) error {
+	nsList, err := c.listNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+	targeted := 0
+	for i := range nsList {
+		ok, err := targetsNamespace(puller, &nsList[i])
+		if err != nil {
+			return err
+		}
+		if ok {
+			targeted++
+		}
+	}
+	progress := puller.Status.Progress
+	if puller.Status.TargetNamespaces == targeted && (progress == nil || progress.Total == targeted) {
+		return nil
+	}
+	patched := puller.DeepCopy()
+	patched.Status.TargetNamespaces = targeted
+	if patched.Status.Progress != nil {
+		patched.Status.Progress.Total = targeted
+	}
+	return c.Client.Status().Patch(ctx, patched, client.MergeFrom(puller))
+}
+
+// desiredSecret is a secret the puller distributes, with its output.
+type desiredSecret struct {
+	secret *corev1.Secret
+	output pullerv1beta1.Output
+}
+
+// desiredSecrets builds the secrets the puller distributes to the namespace.
+// The immutable versions of the image pull secrets are named after their
+// content hash.
+func (c *Controller) desiredSecrets(puller *pullerv1beta1.Puller, namespace string) ([]desiredSecret, error) {
+	secrets, outputs, err := newSecrets(puller)
+	if err != nil {
+		return nil, err
+	}
+	immutable := pullerv1beta1.ImmutableSecrets(puller)
+	desired := make([]desiredSecret, 0, len(secrets))
+	for i, secret := range secrets {
+		secret.SetNamespace(namespace)
+		if err := controllerutil.SetOwnerReference(puller, secret, c.Scheme); err != nil {
+			return nil, err
+		}
+		versioned := immutable && outputs[i].Format.IsPullSecret()
+		if versioned {
+			secret.Labels[OutputLabelKey] = outputs[i].Name
+			secret.Immutable = &versioned
+		}
+		if err := setContentHash(secret); err != nil {
+			return nil, err
+		}
+		if versioned {
+			secret.Name = pullerv1beta1.VersionedName(secret.Name, secret.Annotations[ContentHashAnnotationKey])
+		}
+		desired = append(desired, desiredSecret{secret: secret, output: outputs[i]})
+	}
+	return desired, nil
+}
+
+// syncNamespace distributes the secrets of the puller to the namespace and
+// references the image pull secrets from the service accounts there, in
+// place of their previous versions.
+func (c *Controller) syncNamespace(ctx context.Context, puller *pullerv1beta1.Puller, namespace string) (err error) {
+	ctx, span := tracing.Start(ctx, "syncNamespace", attribute.String("namespace", namespace))
+	defer func() {
+		c.state.recordNamespace(puller.Name, namespace, err)
+		tracing.End(span, err)
+	}()
+
+	secrets, err := c.desiredSecrets(puller, namespace)
+	if err != nil {
+		return err
+	}
+	for _, d := range secrets {
+		if err := c.ensureSecret(ctx, puller, d.secret); err != nil {
+			return err
+		}
+	}
+	for _, d := range secrets {
+		if !d.output.Format.IsPullSecret() {
+			continue
+		}
+		if err := c.ensurerServiceAccount(ctx, puller, namespace, d.secret.Name); err != nil {
+			return err
+		}
+		if err := c.retireVersions(ctx, puller, namespace, d.output.Name, d.secret.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package puller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+// newNamespacePuller returns a valid puller distributed to every namespace.
+func newNamespacePuller() *pullerv1beta1.Puller {
+	return &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller", Finalizers: []string{FinalizerKey}},
+		Spec: pullerv1beta1.PullerSpec{
+			Registries: []pullerv1beta1.Registry{{
+				Server: "harbor.corp",
+				Credentials: pullerv1beta1.RegistryCredentials{
+					Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "secret"},
+				},
+			}},
+		},
+	}
+}
+
+func TestReconcileNamespaceSkips(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*pullerv1beta1.Puller)
+		dryRun bool
+	}{
+		{name: "suspended", mutate: func(p *pullerv1beta1.Puller) { p.Spec.Suspend = true }},
+		{name: "plan mode", mutate: func(p *pullerv1beta1.Puller) { p.Spec.Mode = pullerv1beta1.ModePlan }},
+		{name: "dry run", dryRun: true},
+		{name: "without finalizer", mutate: func(p *pullerv1beta1.Puller) { p.Finalizers = nil }},
+		{
+			name: "invalid",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.Rotation = &pullerv1beta1.Rotation{Strategy: "Bogus"}
+			},
+		},
+		{
+			name: "not rolled out",
+			mutate: func(p *pullerv1beta1.Puller) {
+				p.Spec.RolloutStrategy = &pullerv1beta1.RolloutStrategy{
+					Canary: &metav1.LabelSelector{MatchLabels: map[string]string{"canary": "true"}},
+				}
+				p.Status.Rollout = &pullerv1beta1.RolloutStatus{Phase: pullerv1beta1.RolloutPhaseProgressing}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puller := newNamespacePuller()
+			if tt.mutate != nil {
+				tt.mutate(puller)
+			}
+			c := newTestController(puller, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+			c.DryRun = tt.dryRun
+			ctx := context.Background()
+			if _, err := c.ReconcileNamespace(ctx, namespaceRequest(puller.Name, "default")); err != nil {
+				t.Fatalf("ReconcileNamespace() error = %v", err)
+			}
+			if actions := c.KubeClient.(*kubefake.Clientset).Actions(); len(actions) != 0 {
+				t.Errorf("wrote %d times, want no write, first %v", len(actions), actions[0])
+			}
+			got := &pullerv1beta1.Puller{}
+			if err := c.Client.Get(ctx, client.ObjectKeyFromObject(puller), got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Status.TargetNamespaces != 0 {
+				t.Errorf("status.targetNamespaces = %d, want the status untouched", got.Status.TargetNamespaces)
+			}
+		})
+	}
+}
+
+func TestReconcileNamespaceCountsTargets(t *testing.T) {
+	puller := newNamespacePuller()
+	puller.Status.TargetNamespaces = 1
+	puller.Status.Progress = &pullerv1beta1.Progress{Namespace: "a", Synced: 1, Total: 1}
+	// the secret in the new namespace is not managed by puller, the
+	// namespace is counted nonetheless
+	unmanaged := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretNamesFor(puller)[0], Namespace: "b"}}
+	c := newTestController(puller, unmanaged,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+	)
+	ctx := context.Background()
+	if _, err := c.ReconcileNamespace(ctx, namespaceRequest(puller.Name, "b")); err != nil {
+		t.Fatalf("ReconcileNamespace() error = %v", err)
+	}
+
+	got := &pullerv1beta1.Puller{}
+	if err := c.Client.Get(ctx, client.ObjectKeyFromObject(puller), got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.TargetNamespaces != 2 || got.Status.Progress == nil || got.Status.Progress.Total != 2 {
+		t.Errorf("status = %d target namespaces, progress %+v, want 2 of them", got.Status.TargetNamespaces, got.Status.Progress)
+	}
+	if got.Status.Progress != nil && got.Status.Progress.Synced != 1 {
+		t.Errorf("progress synced = %d, want the checkpoint kept", got.Status.Progress.Synced)
+	}
+}
@@ -0,0 +1,96 @@
+package puller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// listNamespaces returns the namespaces the controller operates in.
+func (c *Controller) listNamespaces(ctx context.Context) ([]corev1.Namespace, error) {
+	if len(c.WatchNamespaces) == 0 {
+		nsList := &corev1.NamespaceList{}
+		if err := c.Client.List(ctx, nsList); err != nil {
+			return nil, err
+		}
+		return c.selectNamespaces(nsList.Items), nil
+	}
+
+	namespaces := make([]corev1.Namespace, 0, len(c.WatchNamespaces))
+	for _, name := range c.WatchNamespaces {
+		ns, err := c.getNamespace(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if ns != nil {
+			namespaces = append(namespaces, *ns)
+		}
+	}
+	return namespaces, nil
+}
+
+// getNamespace returns the namespace, or nil if it does not exist or the
+// controller does not operate in it.
+func (c *Controller) getNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
+	ns := &corev1.Namespace{}
+	if len(c.WatchNamespaces) == 0 {
+		if err := c.Client.Get(ctx, types.NamespacedName{Name: name}, ns); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+	} else {
+		if !c.watches(name) {
+			return nil, nil
+		}
+		// namespaces are not cached when the controller is restricted to a
+		// namespace set, it may not be allowed to watch them
+		err := c.APIReader.Get(ctx, types.NamespacedName{Name: name}, ns)
+		switch {
+		case apierrors.IsNotFound(err):
+			return nil, nil
+		case apierrors.IsForbidden(err):
+			// without the permission to read the namespace, its labels
+			// are unknown
+			ns = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		case err != nil:
+			return nil, err
+		}
+	}
+	if selected := c.selectNamespaces([]corev1.Namespace{*ns}); len(selected) == 0 {
+		return nil, nil
+	}
+	return ns, nil
+}
+
+// watches reports whether the namespace is in the namespace set of the controller.
+func (c *Controller) watches(namespace string) bool {
+	if len(c.WatchNamespaces) == 0 {
+		return true
+	}
+	for _, ns := range c.WatchNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// selectNamespaces filters the namespaces with the namespace selector of the controller.
+func (c *Controller) selectNamespaces(namespaces []corev1.Namespace) []corev1.Namespace {
+	if c.NamespaceSelector == nil || c.NamespaceSelector.Empty() {
+		return namespaces
+	}
+	selected := namespaces[:0:0]
+	for _, ns := range namespaces {
+		if c.NamespaceSelector.Matches(labels.Set(ns.Labels)) {
+			selected = append(selected, ns)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,138 @@
+package puller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/puller-io/puller/pkg/scheme"
+)
+
+// countingReader counts the reads served by a reader.
+type countingReader struct {
+	client.Reader
+	reads int
+}
+
+func (r *countingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	r.reads++
+	return r.Reader.Get(ctx, key, obj, opts...)
+}
+
+func (r *countingReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	r.reads++
+	return r.Reader.List(ctx, list, opts...)
+}
+
+func TestListNamespaces(t *testing.T) {
+	namespace := func(name, team string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"team": team}}}
+	}
+	names := func(namespaces []corev1.Namespace) []string {
+		out := []string{}
+		for _, ns := range namespaces {
+			out = append(out, ns.Name+"/"+ns.Labels["team"])
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		watch []string
+		want  []string
+		// wantAPIReads is the number of reads served by the API server
+		wantAPIReads int
+	}{
+		{name: "cache", want: []string{"a/cached", "b/cached"}},
+		{name: "namespace set", watch: []string{"a", "c", "missing"}, want: []string{"a/api", "c/api"}, wantAPIReads: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the labels tell the reads from the cache and the API server apart
+			c := newTestController(namespace("a", "cached"), namespace("b", "cached"))
+			reader := &countingReader{Reader: fake.NewClientBuilder().WithScheme(scheme.Scheme).
+				WithObjects(namespace("a", "api"), namespace("b", "api"), namespace("c", "api")).Build()}
+			c.APIReader = reader
+			c.WatchNamespaces = tt.watch
+			ctx := context.Background()
+
+			got, err := c.listNamespaces(ctx)
+			if err != nil {
+				t.Fatalf("listNamespaces() error = %v", err)
+			}
+			if !reflect.DeepEqual(names(got), tt.want) {
+				t.Errorf("listNamespaces() = %v, want %v", names(got), tt.want)
+			}
+			if reader.reads != tt.wantAPIReads {
+				t.Errorf("read %d times from the API server, want %d", reader.reads, tt.wantAPIReads)
+			}
+
+			// a namespace out of the set is not read
+			reader.reads = 0
+			ns, err := c.getNamespace(ctx, "b")
+			if err != nil {
+				t.Fatalf("getNamespace() error = %v", err)
+			}
+			if wantB := len(tt.watch) == 0; (ns != nil) != wantB || reader.reads != 0 {
+				t.Errorf("getNamespace(b) = %v after %d API reads, want found %v from the cache", ns, reader.reads, wantB)
+			}
+		})
+	}
+}
+
+func TestListNamespacesForbidden(t *testing.T) {
+	// the controller may read the namespace a, but not b
+	reader := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{"puller": "enabled"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: map[string]string{"puller": "enabled"}}},
+		).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if key.Name == "b" {
+					return apierrors.NewForbidden(corev1.Resource("namespaces"), key.Name, errors.New("denied"))
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		}).
+		Build()
+
+	tests := []struct {
+		name     string
+		selector labels.Selector
+		want     []string
+	}{
+		{name: "without selector", want: []string{"a", "b"}},
+		// the labels of b are unknown, it is not selected
+		{name: "with selector", selector: labels.SelectorFromSet(labels.Set{"puller": "enabled"}), want: []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController()
+			c.APIReader = reader
+			c.WatchNamespaces = []string{"a", "b"}
+			c.NamespaceSelector = tt.selector
+
+			got, err := c.listNamespaces(context.Background())
+			if err != nil {
+				t.Fatalf("listNamespaces() error = %v", err)
+			}
+			names := []string{}
+			for _, ns := range got {
+				names = append(names, ns.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("listNamespaces() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
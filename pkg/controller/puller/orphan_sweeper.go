@@ -0,0 +1,190 @@
+package puller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+// OrphanSweeper periodically finds the secrets and service account references
+// left behind by pullers that no longer exist or no longer target the namespace,
+// for example when a puller was force deleted while the controller was down.
+type OrphanSweeper struct {
+	Controller *Controller
+	// Period is the interval between two sweeps.
+	Period time.Duration
+	// Delete removes the orphans, otherwise they are only reported. It is
+	// changed at runtime with SetDelete.
+	Delete bool
+
+	mu sync.Mutex
+}
+
+var _ manager.LeaderElectionRunnable = &OrphanSweeper{}
+
+// Start runs the sweeper until the context is done.
+func (s *OrphanSweeper) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.sweep(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "failed to sweep orphaned objects")
+		}
+	}, s.Period)
+	return nil
+}
+
+// SetDelete changes whether the next sweeps delete the orphans.
+func (s *OrphanSweeper) SetDelete(del bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Delete = del
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (s *OrphanSweeper) NeedLeaderElection() bool {
+	return true
+}
+
+func (s *OrphanSweeper) sweep(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("orphan-sweeper")
+	c := s.Controller
+	if c.Sharder != nil && !c.Sharder.OwnsShard(0) {
+		// the orphans have no puller to shard them by, the replica holding
+		// the first shard sweeps them
+		return nil
+	}
+
+	// list the managed objects before the pullers, so that an object created
+	// by a puller in the meantime is never mistaken for an orphan.
+	secretList := &corev1.SecretList{}
+	if err := c.Client.List(ctx, secretList, client.HasLabels{SecretLabelKey}); err != nil {
+		return err
+	}
+	saList := &corev1.ServiceAccountList{}
+	if err := c.Client.List(ctx, saList, client.HasLabels{ManagedLabelKey}); err != nil {
+		return err
+	}
+	pullerList := pullerv1beta1.PullerList{}
+	if err := c.Client.List(ctx, &pullerList); err != nil {
+		return err
+	}
+	nsList, err := c.listNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	pullers := make(map[string]*pullerv1beta1.Puller, len(pullerList.Items))
+	for i := range pullerList.Items {
+		pullers[pullerList.Items[i].Name] = &pullerList.Items[i]
+	}
+	// expected holds the secret names each namespace should reference
+	expected := make(map[string]sets.Set[string], len(nsList))
+	for _, ns := range nsList {
+		expected[ns.Name] = sets.New[string]()
+		for _, puller := range pullers {
+			ok, err := targetsNamespace(puller, &ns)
+			if err != nil {
+				logger.Error(err, "failed to match namespace", "puller", puller.Name, "namespace", ns.Name)
+				// keep the objects of a puller that cannot be evaluated
+				ok = true
+			}
+			if puller.Spec.Suspend {
+				// a suspended puller keeps everything it distributed
+				ok = true
+			}
+			if ok {
+				expected[ns.Name].Insert(secretNamesFor(puller)...)
+			}
+		}
+	}
+
+	// the versions of an immutable image pull secret are expected with
+	// their output
+	for _, secret := range secretList.Items {
+		if _, ok := expected[secret.Namespace]; !ok {
+			continue
+		}
+		puller, ok := pullers[secret.Labels[SecretLabelKey]]
+		output := secret.Labels[OutputLabelKey]
+		if ok && output != "" && sets.New[string](secretNamesFor(puller)...).Has(output) && expected[secret.Namespace].Has(output) {
+			expected[secret.Namespace].Insert(secret.Name)
+		}
+	}
+
+	s.mu.Lock()
+	del := s.Delete
+	s.mu.Unlock()
+	action := orphanActionReported
+	if del {
+		action = orphanActionDeleted
+	}
+
+	for _, secret := range secretList.Items {
+		if _, ok := expected[secret.Namespace]; !ok {
+			// the controller does not operate in the namespace, so the
+			// objects there are left alone
+			continue
+		}
+		puller, ok := pullers[secret.Labels[SecretLabelKey]]
+		if ok && expected[secret.Namespace].Has(secret.Name) && (sets.New[string](secretNamesFor(puller)...).Has(secret.Name) || secret.Labels[OutputLabelKey] != "") {
+			continue
+		}
+		logger.Info("Found orphaned secret", "namespace", secret.Namespace, "name", secret.Name, "action", action)
+		if del {
+			if err := c.waitForWrite(ctx); err != nil {
+				return err
+			}
+			err := c.KubeClient.CoreV1().Secrets(secret.Namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{
+				Preconditions: &metav1.Preconditions{UID: &secret.UID},
+			})
+			if err != nil && !apierrors.IsNotFound(err) {
+				logger.Error(err, "failed to delete orphaned secret", "namespace", secret.Namespace, "name", secret.Name)
+				continue
+			}
+		}
+		orphanSecrets.WithLabelValues(action).Inc()
+	}
+
+	for _, sa := range saList.Items {
+		if _, ok := expected[sa.Namespace]; !ok {
+			continue
+		}
+		orphans := managedSecrets(&sa).Difference(expected[sa.Namespace])
+		if orphans.Len() == 0 {
+			continue
+		}
+		logger.Info("Found orphaned image pull secret references", "namespace", sa.Namespace, "name", sa.Name,
+			"secrets", sets.List(orphans), "action", action)
+		if del {
+			if err := c.releaseServiceAccount(ctx, sa.Namespace, sa.Name, orphans, false); err != nil {
+				logger.Error(err, "failed to release orphaned service account", "namespace", sa.Namespace, "name", sa.Name)
+				continue
+			}
+		}
+		orphanServiceAccountRefs.WithLabelValues(action).Add(float64(orphans.Len()))
+	}
+	return nil
+}
+
+// targetsNamespace reports whether the puller distributes its secret to the namespace.
+func targetsNamespace(puller *pullerv1beta1.Puller, ns *corev1.Namespace) (bool, error) {
+	if puller.Spec.NamespaceSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(puller.Spec.NamespaceSelector)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
@@ -0,0 +1,68 @@
+package puller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestSweepSkipsUnselectedNamespaces(t *testing.T) {
+	namespace := func(name string, selected bool) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}}}
+		if selected {
+			ns.Labels["puller"] = "enabled"
+		}
+		return ns
+	}
+	orphan := func(namespace string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      "gone",
+			Namespace: namespace,
+			Labels:    map[string]string{SecretLabelKey: "gone"},
+		}}
+	}
+	serviceAccount := func(namespace string) *corev1.ServiceAccount {
+		return &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "default",
+				Namespace:   namespace,
+				Labels:      map[string]string{ManagedLabelKey: "true"},
+				Annotations: map[string]string{ManagedSecretsAnnotationKey: "gone"},
+			},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "gone"}},
+		}
+	}
+
+	c := newTestController(
+		namespace("selected", true), namespace("other", false),
+		orphan("selected"), orphan("other"),
+		serviceAccount("selected"), serviceAccount("other"),
+	)
+	c.NamespaceSelector = labels.SelectorFromSet(labels.Set{"puller": "enabled"})
+	sweeper := &OrphanSweeper{Controller: c, Delete: true}
+	ctx := context.Background()
+	if err := sweeper.sweep(ctx); err != nil {
+		t.Fatalf("sweep() error = %v", err)
+	}
+
+	secrets := c.KubeClient.CoreV1().Secrets
+	if _, err := secrets("selected").Get(ctx, "gone", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("orphaned secret in a selected namespace was not deleted, error = %v", err)
+	}
+	if _, err := secrets("other").Get(ctx, "gone", metav1.GetOptions{}); err != nil {
+		t.Errorf("secret in an unselected namespace was deleted, error = %v", err)
+	}
+	for ns, want := range map[string]int{"selected": 0, "other": 1} {
+		sa, err := c.KubeClient.CoreV1().ServiceAccounts(ns).Get(ctx, "default", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get service account of %s: %v", ns, err)
+		}
+		if got := len(sa.ImagePullSecrets); got != want {
+			t.Errorf("service account of %s has %d image pull secrets, want %d", ns, got, want)
+		}
+	}
+}
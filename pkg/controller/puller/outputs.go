@@ -0,0 +1,172 @@
+package puller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/registry"
+)
+
+const (
+	// ConfigJSONKey holds the docker config in the ConfigJSON outputs.
+	ConfigJSONKey = "config.json"
+	// ArgoCDSecretTypeLabelKey marks the secrets Argo CD reads.
+	ArgoCDSecretTypeLabelKey = "argocd.argoproj.io/secret-type"
+	// ArgoCDRepoCreds is the Argo CD secret type of the credentials shared
+	// by the repositories under a URL.
+	ArgoCDRepoCreds = "repo-creds"
+)
+
+// newSecrets builds the secrets of the outputs of the puller, and returns
+// them with their outputs.
+func newSecrets(puller *pullerv1beta1.Puller) ([]*corev1.Secret, []pullerv1beta1.Output, error) {
+	outputs := pullerv1beta1.OutputsOf(puller)
+	secrets := make([]*corev1.Secret, 0, len(outputs))
+	for _, output := range outputs {
+		secret, err := newOutputSecret(puller, output)
+		if err != nil {
+			return nil, nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, outputs, nil
+}
+
+// secretNamesFor returns the names of the secrets of the outputs.
+func secretNamesFor(puller *pullerv1beta1.Puller) []string {
+	outputs := pullerv1beta1.OutputsOf(puller)
+	names := make([]string, 0, len(outputs))
+	for _, output := range outputs {
+		names = append(names, output.Name)
+	}
+	return names
+}
+
+func newOutputSecret(puller *pullerv1beta1.Puller, output pullerv1beta1.Output) (*corev1.Secret, error) {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   output.Name,
+			Labels: map[string]string{},
+		},
+	}
+	switch output.Format {
+	case pullerv1beta1.OutputFormatDockerConfigJSON:
+		content, err := buildDockerConfigJSON(puller.Spec.Registries)
+		if err != nil {
+			return nil, err
+		}
+		secret.Type = corev1.SecretTypeDockerConfigJson
+		secret.Data = map[string][]byte{corev1.DockerConfigJsonKey: content}
+		if t := puller.Spec.SecretTemplate; t != nil && len(t.Type) != 0 {
+			secret.Type = t.Type
+		}
+	case pullerv1beta1.OutputFormatDockerCfg:
+		auths, err := buildDockerConfigAuths(puller.Spec.Registries)
+		if err != nil {
+			return nil, err
+		}
+		content, err := json.Marshal(auths)
+		if err != nil {
+			return nil, err
+		}
+		secret.Type = corev1.SecretTypeDockercfg
+		secret.Data = map[string][]byte{corev1.DockerConfigKey: content}
+	case pullerv1beta1.OutputFormatConfigJSON:
+		content, err := buildDockerConfigJSON(puller.Spec.Registries)
+		if err != nil {
+			return nil, err
+		}
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = map[string][]byte{ConfigJSONKey: content}
+	case pullerv1beta1.OutputFormatFluxHelmRepository:
+		_, username, password, err := outputCredentials(puller, output)
+		if err != nil {
+			return nil, err
+		}
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = map[string][]byte{
+			corev1.BasicAuthUsernameKey: []byte(username),
+			corev1.BasicAuthPasswordKey: []byte(password),
+		}
+	case pullerv1beta1.OutputFormatArgoCDRepository:
+		server, username, password, err := outputCredentials(puller, output)
+		if err != nil {
+			return nil, err
+		}
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Labels[ArgoCDSecretTypeLabelKey] = ArgoCDRepoCreds
+		// the OCI repositories are matched by prefix, without scheme
+		secret.Data = map[string][]byte{
+			"type":                      []byte("helm"),
+			"url":                       []byte(server),
+			"enableOCI":                 []byte("true"),
+			corev1.BasicAuthUsernameKey: []byte(username),
+			corev1.BasicAuthPasswordKey: []byte(password),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", output.Format)
+	}
+
+	if t := puller.Spec.SecretTemplate; t != nil {
+		for k, v := range t.Labels {
+			secret.Labels[k] = v
+		}
+		if len(t.Annotations) != 0 {
+			secret.Annotations = make(map[string]string, len(t.Annotations))
+			for k, v := range t.Annotations {
+				secret.Annotations[k] = v
+			}
+		}
+	}
+	secret.Labels[SecretLabelKey] = puller.Name
+	return secret, nil
+}
+
+// outputCredentials returns the normalized server, the username and the
+// password of the registry of an output of a single registry. They are blank
+// when the credentials of the registry are not rendered, e.g. because they
+// expired, so that its distributed secrets stop holding them, as the docker
+// configs drop its entry.
+func outputCredentials(puller *pullerv1beta1.Puller, output pullerv1beta1.Output) (string, string, string, error) {
+	key := registry.ConfigKey(output.Server)
+	for _, r := range puller.Spec.Registries {
+		if registry.ConfigKey(r.Server) == key {
+			return basicCredentialsOf(puller, output.Server)
+		}
+	}
+	return registry.NormalizeServer(output.Server), "", "", nil
+}
+
+// basicCredentialsOf returns the normalized server, the username and the
+// password of the registry of the server, whose credentials must be resolved.
+func basicCredentialsOf(puller *pullerv1beta1.Puller, server string) (string, string, string, error) {
+	key := registry.ConfigKey(server)
+	for _, r := range puller.Spec.Registries {
+		if registry.ConfigKey(r.Server) != key {
+			continue
+		}
+		b := r.Credentials.Basic
+		if b == nil {
+			return "", "", "", fmt.Errorf("registry %s has no username and password", r.Server)
+		}
+		username, password := b.Username, b.Password
+		if b.Auth != "" {
+			decoded, err := decodeDockerConfigFieldAuth(b.Auth)
+			if err != nil {
+				return "", "", "", fmt.Errorf("registry %s: %w", r.Server, err)
+			}
+			username, password, _ = strings.Cut(decoded, ":")
+		}
+		return registry.NormalizeServer(r.Server), username, password, nil
+	}
+	return "", "", "", fmt.Errorf("no registry matches server %s", server)
+}
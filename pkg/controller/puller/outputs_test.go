@@ -0,0 +1,115 @@
+package puller
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+func TestNewOutputSecret(t *testing.T) {
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull"},
+		Spec: pullerv1beta1.PullerSpec{
+			SecretTemplate: &pullerv1beta1.SecretTemplate{Labels: map[string]string{"team": "a"}},
+			Registries: []pullerv1beta1.Registry{
+				{
+					Server: "harbor.corp",
+					Credentials: pullerv1beta1.RegistryCredentials{
+						Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "secret"},
+					},
+				},
+				{
+					Server: "https://Charts.corp/helm/",
+					Credentials: pullerv1beta1.RegistryCredentials{
+						Basic: &pullerv1beta1.BasicCredentials{Auth: "aGVsbTp0b2tlbg=="},
+					},
+				},
+			},
+		},
+	}
+	const auths = `{"charts.corp/helm":{"auth":"aGVsbTp0b2tlbg=="},"harbor.corp":{"username":"user","password":"secret","auth":"dXNlcjpzZWNyZXQ="}}`
+
+	tests := []struct {
+		name       string
+		output     pullerv1beta1.Output
+		wantType   corev1.SecretType
+		wantData   map[string]string
+		wantLabels map[string]string
+	}{
+		{
+			name:     "docker config json",
+			output:   pullerv1beta1.Output{Format: pullerv1beta1.OutputFormatDockerConfigJSON, Name: "pull"},
+			wantType: corev1.SecretTypeDockerConfigJson,
+			wantData: map[string]string{corev1.DockerConfigJsonKey: `{"auths":` + auths + `}`},
+		},
+		{
+			name:     "dockercfg",
+			output:   pullerv1beta1.Output{Format: pullerv1beta1.OutputFormatDockerCfg, Name: "pull-dockercfg"},
+			wantType: corev1.SecretTypeDockercfg,
+			wantData: map[string]string{corev1.DockerConfigKey: auths},
+		},
+		{
+			name:     "config.json",
+			output:   pullerv1beta1.Output{Format: pullerv1beta1.OutputFormatConfigJSON, Name: "pull-configjson"},
+			wantType: corev1.SecretTypeOpaque,
+			wantData: map[string]string{ConfigJSONKey: `{"auths":` + auths + `}`},
+		},
+		{
+			name:     "flux helm repository",
+			output:   pullerv1beta1.Output{Format: pullerv1beta1.OutputFormatFluxHelmRepository, Name: "pull-flux", Server: "charts.corp/helm"},
+			wantType: corev1.SecretTypeOpaque,
+			wantData: map[string]string{"username": "helm", "password": "token"},
+		},
+		{
+			name:       "argo cd repository",
+			output:     pullerv1beta1.Output{Format: pullerv1beta1.OutputFormatArgoCDRepository, Name: "pull-argo", Server: "https://Charts.corp/helm"},
+			wantType:   corev1.SecretTypeOpaque,
+			wantData:   map[string]string{"type": "helm", "url": "charts.corp/helm", "enableOCI": "true", "username": "helm", "password": "token"},
+			wantLabels: map[string]string{ArgoCDSecretTypeLabelKey: ArgoCDRepoCreds},
+		},
+		{
+			// the registry is not rendered, e.g. because its credentials
+			// expired
+			name:     "flux helm repository of a skipped registry",
+			output:   pullerv1beta1.Output{Format: pullerv1beta1.OutputFormatFluxHelmRepository, Name: "pull-flux", Server: "gone.corp"},
+			wantType: corev1.SecretTypeOpaque,
+			wantData: map[string]string{"username": "", "password": ""},
+		},
+		{
+			name:       "argo cd repository of a skipped registry",
+			output:     pullerv1beta1.Output{Format: pullerv1beta1.OutputFormatArgoCDRepository, Name: "pull-argo", Server: "https://Gone.corp/charts/"},
+			wantType:   corev1.SecretTypeOpaque,
+			wantData:   map[string]string{"type": "helm", "url": "gone.corp/charts", "enableOCI": "true", "username": "", "password": ""},
+			wantLabels: map[string]string{ArgoCDSecretTypeLabelKey: ArgoCDRepoCreds},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, err := newOutputSecret(puller, tt.output)
+			if err != nil {
+				t.Fatalf("newOutputSecret() error = %v", err)
+			}
+			if secret.Name != tt.output.Name || secret.Type != tt.wantType {
+				t.Errorf("secret %s of type %s, want %s of type %s", secret.Name, secret.Type, tt.output.Name, tt.wantType)
+			}
+			data := make(map[string]string, len(secret.Data))
+			for k, v := range secret.Data {
+				data[k] = string(v)
+			}
+			if !reflect.DeepEqual(data, tt.wantData) {
+				t.Errorf("data = %v, want %v", data, tt.wantData)
+			}
+			wantLabels := map[string]string{"team": "a", SecretLabelKey: "pull"}
+			for k, v := range tt.wantLabels {
+				wantLabels[k] = v
+			}
+			if !reflect.DeepEqual(secret.Labels, wantLabels) {
+				t.Errorf("labels = %v, want %v", secret.Labels, wantLabels)
+			}
+		})
+	}
+}
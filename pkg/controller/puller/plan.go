@@ -0,0 +1,161 @@
+package puller
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+// maxPlannedChanges bounds the number of changes listed in the status, so a
+// puller targeting many namespaces does not exceed the object size limit.
+const maxPlannedChanges = 200
+
+// planNamespace computes the writes syncNamespace would make in the
+// namespace, without making them.
+func (c *Controller) planNamespace(ctx context.Context, puller *pullerv1beta1.Puller, namespace string) ([]pullerv1beta1.PlannedChange, error) {
+	secrets, err := c.desiredSecrets(puller, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		changes []pullerv1beta1.PlannedChange
+		names   []string
+	)
+	for _, d := range secrets {
+		secret := d.secret
+		if d.output.Format.IsPullSecret() {
+			names = append(names, secret.Name)
+		}
+		got, err := c.getSecret(ctx, secret.Namespace, secret.Name)
+		if err != nil {
+			return changes, err
+		}
+		op, err := decideSecret(puller, got, secret)
+		if err != nil {
+			return changes, err
+		}
+		secretChange := func(action pullerv1beta1.PlanAction) {
+			changes = append(changes, pullerv1beta1.PlannedChange{
+				Action:    action,
+				Kind:      "Secret",
+				Namespace: secret.Namespace,
+				Name:      secret.Name,
+			})
+		}
+		switch op {
+		case secretOpCreate:
+			secretChange(pullerv1beta1.PlanActionCreate)
+		case secretOpRecreate:
+			secretChange(pullerv1beta1.PlanActionDelete)
+			secretChange(pullerv1beta1.PlanActionCreate)
+		case secretOpUpdate:
+			secretChange(pullerv1beta1.PlanActionUpdate)
+		}
+	}
+
+	saList := &corev1.ServiceAccountList{}
+	if err := c.Client.List(ctx, saList, client.InNamespace(namespace)); err != nil {
+		return changes, err
+	}
+	for _, sa := range saList.Items {
+		for _, name := range names {
+			if !hasImagePullSecret(&sa, name) {
+				changes = append(changes, pullerv1beta1.PlannedChange{
+					Action:    pullerv1beta1.PlanActionPatch,
+					Kind:      "ServiceAccount",
+					Namespace: sa.Namespace,
+					Name:      sa.Name,
+				})
+				break
+			}
+		}
+	}
+	return changes, nil
+}
+
+// planStale computes the deletions of the secrets the puller distributed
+// before and no longer distributes: the ones of the namespaces it does not
+// target anymore, of the outputs removed from its spec, and the previous
+// versions of its image pull secrets.
+func (c *Controller) planStale(ctx context.Context, puller *pullerv1beta1.Puller, namespaces []string) ([]pullerv1beta1.PlannedChange, error) {
+	secrets, err := c.desiredSecrets(puller, "")
+	if err != nil {
+		return nil, err
+	}
+	desired := sets.New[string]()
+	for _, d := range secrets {
+		desired.Insert(d.secret.Name)
+	}
+	targeted := sets.New[string](namespaces...)
+
+	secretList := &corev1.SecretList{}
+	if err := c.Client.List(ctx, secretList, client.MatchingLabels{SecretLabelKey: puller.Name}); err != nil {
+		return nil, err
+	}
+	sort.Slice(secretList.Items, func(i, j int) bool {
+		a, b := secretList.Items[i], secretList.Items[j]
+		return a.Namespace < b.Namespace || a.Namespace == b.Namespace && a.Name < b.Name
+	})
+	var changes []pullerv1beta1.PlannedChange
+	patched := sets.New[types.NamespacedName]()
+	for _, secret := range secretList.Items {
+		if targeted.Has(secret.Namespace) && desired.Has(secret.Name) {
+			continue
+		}
+		changes = append(changes, pullerv1beta1.PlannedChange{
+			Action:    pullerv1beta1.PlanActionDelete,
+			Kind:      "Secret",
+			Namespace: secret.Namespace,
+			Name:      secret.Name,
+		})
+		saList := &corev1.ServiceAccountList{}
+		if err := c.Client.List(ctx, saList, client.InNamespace(secret.Namespace),
+			client.MatchingFields{ManagedSecretsIndex: secret.Name}); err != nil {
+			return changes, err
+		}
+		for _, sa := range saList.Items {
+			key := client.ObjectKeyFromObject(&sa)
+			if patched.Has(key) {
+				continue
+			}
+			patched.Insert(key)
+			changes = append(changes, pullerv1beta1.PlannedChange{
+				Action:    pullerv1beta1.PlanActionPatch,
+				Kind:      "ServiceAccount",
+				Namespace: sa.Namespace,
+				Name:      sa.Name,
+			})
+		}
+	}
+	return changes, nil
+}
+
+// newPlan builds the plan published in the status of the puller.
+func newPlan(puller *pullerv1beta1.Puller, changes []pullerv1beta1.PlannedChange) *pullerv1beta1.Plan {
+	plan := &pullerv1beta1.Plan{
+		ObservedGeneration: puller.Generation,
+		Total:              len(changes),
+	}
+	if len(changes) > maxPlannedChanges {
+		changes = changes[:maxPlannedChanges]
+	}
+	plan.Changes = changes
+	return plan
+}
+
+// hasImagePullSecret reports whether the service account references the secret.
+func hasImagePullSecret(sa *corev1.ServiceAccount, name string) bool {
+	for _, im := range sa.ImagePullSecrets {
+		if im.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package puller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+func TestPlanStale(t *testing.T) {
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller", UID: "uid"},
+		Spec: pullerv1beta1.PullerSpec{
+			Registries: []pullerv1beta1.Registry{{
+				Server: "registry.example.com",
+				Credentials: pullerv1beta1.RegistryCredentials{
+					Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "password"},
+				},
+			}},
+		},
+	}
+	secret := func(namespace, name string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{SecretLabelKey: puller.Name},
+		}}
+	}
+	serviceAccount := func(namespace string, secrets string) *corev1.ServiceAccount {
+		return &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+			Name:        "default",
+			Namespace:   namespace,
+			Labels:      map[string]string{ManagedLabelKey: "true"},
+			Annotations: map[string]string{ManagedSecretsAnnotationKey: secrets},
+		}}
+	}
+	c := newTestController(
+		secret("targeted", "puller"),
+		secret("targeted", "removed-output"),
+		secret("untargeted", "puller"),
+		serviceAccount("targeted", "puller,removed-output"),
+		serviceAccount("untargeted", "puller"),
+	)
+
+	changes, err := c.planStale(context.Background(), puller, []string{"targeted"})
+	if err != nil {
+		t.Fatalf("planStale() error = %v", err)
+	}
+	want := []pullerv1beta1.PlannedChange{
+		{Action: pullerv1beta1.PlanActionDelete, Kind: "Secret", Namespace: "targeted", Name: "removed-output"},
+		{Action: pullerv1beta1.PlanActionPatch, Kind: "ServiceAccount", Namespace: "targeted", Name: "default"},
+		{Action: pullerv1beta1.PlanActionDelete, Kind: "Secret", Namespace: "untargeted", Name: "puller"},
+		{Action: pullerv1beta1.PlanActionPatch, Kind: "ServiceAccount", Namespace: "untargeted", Name: "default"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("planStale() = %+v, want %+v", changes, want)
+	}
+}
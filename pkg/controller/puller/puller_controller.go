@@ -1,18 +1,30 @@
 package puller
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/time/rate"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
@@ -20,42 +32,136 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	pullerv1alpha1 "github.com/puller-io/puller/pkg/apis/puller/v1alpha1"
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/apis/puller/validation"
+	"github.com/puller-io/puller/pkg/registry"
+	"github.com/puller-io/puller/pkg/sharding"
+	"github.com/puller-io/puller/pkg/tracing"
 )
 
 const (
-	ControllerName = "puller-controller"
-	SecretLabelKey = "puller.io/name"
-	FinalizerKey   = "puller.io/finalizer"
+	ControllerName          = "puller-controller"
+	NamespaceControllerName = "puller-namespace-controller"
+	SecretLabelKey          = "puller.io/name"
+	FinalizerKey            = "puller.io/finalizer"
+	// ManagedLabelKey marks the service accounts patched by puller.
+	ManagedLabelKey = "puller.io/managed"
+	// ManagedSecretsAnnotationKey records the image pull secrets added to a
+	// service account by puller, separated by commas.
+	ManagedSecretsAnnotationKey = "puller.io/image-pull-secrets"
+	// ManagedSecretsIndex indexes the service accounts by the image pull
+	// secrets added by puller.
+	ManagedSecretsIndex = "puller.io/managed-secrets"
+	// ContentHashAnnotationKey records the hash of the content applied to a
+	// secret, so that unchanged secrets are not written again.
+	ContentHashAnnotationKey = "puller.io/content-hash"
+	// FieldManager is the field manager used for the writes of puller.
+	FieldManager = "puller"
+	// OutputLabelKey records the output an immutable version of an image
+	// pull secret belongs to.
+	OutputLabelKey = "puller.io/output"
+	// SupersededAtAnnotationKey records when a version of an image pull
+	// secret was superseded by a new one, it is deleted after the grace
+	// period of the rotation.
+	SupersededAtAnnotationKey = "puller.io/superseded-at"
+	// HistoryLabelKey records the UID of the puller a revision of the
+	// credentials belongs to.
+	HistoryLabelKey = "puller.io/history-of"
+	// RevisionLabelKey records the number of a revision of the credentials.
+	RevisionLabelKey = "puller.io/revision"
+	// RevisionServersAnnotationKey records the servers of the registries of
+	// a revision, by their docker config key.
+	RevisionServersAnnotationKey = "puller.io/servers"
+	// RevisionValidityAnnotationKey records the validity window set in the
+	// spec for the registries of a revision, by their docker config key.
+	RevisionValidityAnnotationKey = "puller.io/validity"
 )
 
 type Controller struct {
 	client.Client
-	Scheme        *runtime.Scheme
+	// APIReader reads from the API server directly, for the objects
+	// that are not held in the cache.
+	APIReader client.Reader
+	Scheme    *runtime.Scheme
+	// KubeClient is only used to write, all reads are served by the cache.
 	KubeClient    kubernetes.Interface
 	EventRecorder record.EventRecorder
+	// DryRun puts every puller in plan mode.
+	DryRun bool
+	// ResyncPeriod is the period the pullers are reconciled again, their
+	// secrets are then rendered again from the spec.
+	ResyncPeriod time.Duration
+	// ConcurrentNamespaceSyncs is the number of namespace work items that
+	// are allowed to sync concurrently.
+	ConcurrentNamespaceSyncs int
+	// FanOutWorkers is the number of namespaces a puller syncs in parallel,
+	// it is changed at runtime with SetFanOutWorkers.
+	FanOutWorkers int
+	// WriteLimiter limits the writes to the target namespaces, nil means
+	// no limit.
+	WriteLimiter *rate.Limiter
+	// WatchNamespaces, if set, restricts the controller to these namespaces.
+	WatchNamespaces []string
+	// NamespaceSelector, if set, restricts the controller to the namespaces
+	// it selects.
+	NamespaceSelector labels.Selector
+	// Sharder, if set, restricts the controller to the pullers of the
+	// shards held by the replica.
+	Sharder *sharding.Sharder
+	// RegistryChecker checks the credentials against the registries before
+	// each stage of a staged rollout, nil skips the checks.
+	RegistryChecker *registry.Checker
+	// HistoryNamespace holds the revisions of the credentials of the
+	// pullers, empty disables the history.
+	HistoryNamespace string
+
+	// shardEvents enqueues the pullers of the shards acquired by the replica.
+	shardEvents chan event.GenericEvent
+	// state is the outcome of the last syncs, served by the debug endpoint.
+	state distributionState
+	// revisions are the revisions of the credentials last recorded.
+	revisions recordedRevisions
+	// historyMissing warns once of a missing history namespace.
+	historyMissing sync.Once
+	// settingsMu guards the settings changed at runtime.
+	settingsMu sync.RWMutex
 }
 
 // Reconcile performs a full reconciliation for the object referred to by the Request.
 func (c *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "Reconcile", attribute.String("puller", req.Name))
+	result, err := c.reconcile(ctx, req)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (c *Controller) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 	logger.V(4).Info("Reconciling puller", "name", req.NamespacedName.Name)
 
-	obj := pullerv1alpha1.Puller{}
+	obj := pullerv1beta1.Puller{}
 	err := c.Client.Get(ctx, req.NamespacedName, &obj)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
+			c.state.forget(req.Name)
+			c.revisions.forget(req.Name)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{Requeue: true}, err
 	}
 	puller := obj.DeepCopy()
+	if !c.owns(puller.Name) {
+		logger.V(4).Info("Puller belongs to a shard of another replica, skip", "name", puller.Name)
+		return ctrl.Result{}, nil
+	}
 
 	if !puller.DeletionTimestamp.IsZero() {
 		return c.cleanImageSecretName(ctx, puller)
@@ -64,7 +170,7 @@ func (c *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	return c.syncPuller(ctx, puller)
 }
 
-func (c *Controller) removeFinalizer(puller *pullerv1alpha1.Puller) (ctrl.Result, error) {
+func (c *Controller) removeFinalizer(puller *pullerv1beta1.Puller) (ctrl.Result, error) {
 	if !controllerutil.ContainsFinalizer(puller, FinalizerKey) {
 		return ctrl.Result{}, nil
 	}
@@ -78,7 +184,7 @@ func (c *Controller) removeFinalizer(puller *pullerv1alpha1.Puller) (ctrl.Result
 	return ctrl.Result{}, nil
 }
 
-func (c *Controller) ensureFinalizer(puller *pullerv1alpha1.Puller) (ctrl.Result, error) {
+func (c *Controller) ensureFinalizer(puller *pullerv1beta1.Puller) (ctrl.Result, error) {
 	if controllerutil.ContainsFinalizer(puller, FinalizerKey) {
 		return ctrl.Result{}, nil
 	}
@@ -92,7 +198,7 @@ func (c *Controller) ensureFinalizer(puller *pullerv1alpha1.Puller) (ctrl.Result
 	return ctrl.Result{}, nil
 }
 
-func (c *Controller) updateStatusIfNeed(ctx context.Context, puller *pullerv1alpha1.Puller, newStatus pullerv1alpha1.PullerStatus) error {
+func (c *Controller) updateStatusIfNeed(ctx context.Context, puller *pullerv1beta1.Puller, newStatus pullerv1beta1.PullerStatus) error {
 	logger := log.FromContext(ctx)
 	if !equality.Semantic.DeepEqual(puller.Status, newStatus) {
 		puller.Status = newStatus
@@ -101,7 +207,7 @@ func (c *Controller) updateStatusIfNeed(ctx context.Context, puller *pullerv1alp
 			if updateErr == nil {
 				return nil
 			}
-			updated := &pullerv1alpha1.Puller{}
+			updated := &pullerv1beta1.Puller{}
 			if err := c.Client.Get(context.TODO(), client.ObjectKey{Name: puller.Name}, updated); err == nil {
 				puller = updated.DeepCopy()
 				puller.Status = newStatus
@@ -114,59 +220,197 @@ func (c *Controller) updateStatusIfNeed(ctx context.Context, puller *pullerv1alp
 	return nil
 }
 
-func (c *Controller) ensureSecret(ctx context.Context, secret *corev1.Secret) error {
-	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		got, err := c.KubeClient.CoreV1().Secrets(secret.Namespace).Get(ctx, secret.Name, metav1.GetOptions{})
-		if err != nil && apierrors.IsNotFound(err) {
-			_, err := c.KubeClient.CoreV1().Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{})
-			if err != nil {
-				return err
-			}
-			return nil
-		} else if err != nil {
-			return err
+// secretConflictError reports a Secret that already exists in a namespace and
+// is not managed by the puller.
+type secretConflictError struct {
+	namespace string
+	name      string
+	// owner is the other puller managing the secret, if any.
+	owner string
+}
+
+func (e *secretConflictError) Error() string {
+	if e.owner != "" {
+		return fmt.Sprintf("secret %s/%s already exists and is managed by puller %s", e.namespace, e.name, e.owner)
+	}
+	return fmt.Sprintf("secret %s/%s already exists and is not managed by puller", e.namespace, e.name)
+}
+
+// otherPullerOf returns the name of the puller other than the given one that
+// manages the secret, by its label or its owner references, empty if none.
+func otherPullerOf(puller *pullerv1beta1.Puller, secret *corev1.Secret) string {
+	if name := secret.Labels[SecretLabelKey]; name != "" && name != puller.Name {
+		return name
+	}
+	for _, ref := range secret.OwnerReferences {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil || gv.Group != pullerv1beta1.SchemeGroupVersion.Group || ref.Kind != "Puller" {
+			continue
 		}
-		secret.SetResourceVersion(got.GetResourceVersion())
-		_, err = c.KubeClient.CoreV1().Secrets(got.Namespace).Update(ctx, secret, metav1.UpdateOptions{})
-		if err != nil {
-			return err
+		// a puller recreated with the same name takes over the secrets of
+		// the previous one
+		if ref.Name != puller.Name {
+			return ref.Name
 		}
-		return nil
-	})
+	}
+	return ""
+}
+
+// getSecret returns the secret, or nil if it does not exist. The cache only
+// holds the secrets managed by puller, so a secret missing from it is looked
+// up on the API server to find a conflicting one.
+func (c *Controller) getSecret(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	got := &corev1.Secret{}
+	err := c.Client.Get(ctx, key, got)
+	if apierrors.IsNotFound(err) {
+		err = c.APIReader.Get(ctx, key, got)
+	}
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return got, nil
 }
 
-func (c *Controller) ensurerServiceAccount(ctx context.Context, namespace string, name string) error {
-	saList, err := c.KubeClient.CoreV1().ServiceAccounts(namespace).List(ctx, metav1.ListOptions{})
+// secretOp is the write needed to converge a secret.
+type secretOp int
+
+const (
+	secretOpNone secretOp = iota
+	secretOpCreate
+	secretOpUpdate
+	secretOpRecreate
+)
+
+// decideSecret compares the existing secret, nil if it does not exist, with the
+// desired one and returns the write needed to converge it.
+func decideSecret(puller *pullerv1beta1.Puller, got, secret *corev1.Secret) (secretOp, error) {
+	if got == nil {
+		return secretOpCreate, nil
+	}
+
+	// the secret of another puller is never taken over, the two pullers
+	// would take it from each other on every sync
+	if owner := otherPullerOf(puller, got); owner != "" {
+		return secretOpNone, &secretConflictError{namespace: got.Namespace, name: got.Name, owner: owner}
+	}
+	managed := got.Labels[SecretLabelKey] == puller.Name
+	switch {
+	case managed:
+	case puller.Spec.ConflictPolicy == pullerv1beta1.ConflictPolicyAdopt:
+		if got.Type != secret.Type {
+			return secretOpNone, &secretConflictError{namespace: got.Namespace, name: got.Name}
+		}
+	case puller.Spec.ConflictPolicy == pullerv1beta1.ConflictPolicyOverwrite:
+		return secretOpRecreate, nil
+	default:
+		return secretOpNone, &secretConflictError{namespace: got.Namespace, name: got.Name}
+	}
+
+	if got.Type != secret.Type {
+		// the type of a secret is immutable, so it has to be recreated
+		return secretOpRecreate, nil
+	}
+	if managed && got.Annotations[ContentHashAnnotationKey] == secret.Annotations[ContentHashAnnotationKey] {
+		return secretOpNone, nil
+	}
+	if got.Immutable != nil && *got.Immutable && got.Annotations[ContentHashAnnotationKey] != secret.Annotations[ContentHashAnnotationKey] {
+		// the data of an immutable secret cannot be updated
+		return secretOpRecreate, nil
+	}
+	return secretOpUpdate, nil
+}
+
+// ensureSecret applies the secret with server-side apply, the fields set by
+// others on an adopted secret are kept.
+func (c *Controller) ensureSecret(ctx context.Context, puller *pullerv1beta1.Puller, secret *corev1.Secret) (err error) {
+	ctx, span := tracing.Start(ctx, "ensureSecret", attribute.String("namespace", secret.Namespace), attribute.String("secret", secret.Name))
+	defer func() { tracing.End(span, err) }()
+
+	got, err := c.getSecret(ctx, secret.Namespace, secret.Name)
 	if err != nil {
 		return err
 	}
 
-	var errs []error
-	for _, sa := range saList.Items {
-		exists := sets.Set[string]{}
-		for _, im := range sa.ImagePullSecrets {
-			exists.Insert(im.Name)
+	op, err := decideSecret(puller, got, secret)
+	if err != nil {
+		return err
+	}
+	if got != nil && op != secretOpRecreate && got.Annotations[SupersededAtAnnotationKey] != "" {
+		// a superseded version is current again, e.g. after a rollback of
+		// the rotation strategy
+		if err := c.patchSupersededAt(ctx, got, nil); err != nil {
+			return err
 		}
-		if exists.Has(name) {
-			continue
+	}
+	switch op {
+	case secretOpNone:
+		return nil
+	case secretOpRecreate:
+		if err := c.waitForWrite(ctx); err != nil {
+			return err
 		}
-		sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{
-			Name: name,
+		err = c.KubeClient.CoreV1().Secrets(got.Namespace).Delete(ctx, got.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &got.UID},
 		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	if err := c.waitForWrite(ctx); err != nil {
+		return err
+	}
+	_, err = c.KubeClient.CoreV1().Secrets(secret.Namespace).Apply(ctx, secretApplyConfiguration(secret), metav1.ApplyOptions{
+		FieldManager: FieldManager,
+		Force:        true,
+	})
+	return err
+}
 
-		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
-			got, err := c.KubeClient.CoreV1().ServiceAccounts(sa.Namespace).Get(ctx, sa.Name, metav1.GetOptions{})
-			if err != nil && apierrors.IsNotFound(err) {
-				return nil
-			} else if err != nil {
-				return err
+// ensurerServiceAccount references the secret of the puller from the
+// service accounts of the namespace and records it as managed. A reference
+// the puller did not record, e.g. one added by a version of puller that did
+// not record them, is adopted when the secret carries the label of the
+// puller, so that the cleanup removes it.
+func (c *Controller) ensurerServiceAccount(ctx context.Context, puller *pullerv1beta1.Puller, namespace string, name string) (err error) {
+	ctx, span := tracing.Start(ctx, "ensureServiceAccount", attribute.String("namespace", namespace))
+	defer func() { tracing.End(span, err) }()
+
+	saList := &corev1.ServiceAccountList{}
+	if err := c.Client.List(ctx, saList, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+
+	var (
+		errs  []error
+		owned *bool
+	)
+	for _, sa := range saList.Items {
+		if hasImagePullSecret(&sa, name) {
+			if managedSecrets(&sa).Has(name) {
+				continue
 			}
-			sa.SetResourceVersion(got.ResourceVersion)
-			_, err = c.KubeClient.CoreV1().ServiceAccounts(sa.Namespace).Update(ctx, &sa, metav1.UpdateOptions{})
-			if err != nil {
-				return err
+			if owned == nil {
+				secret, err := c.getSecret(ctx, namespace, name)
+				if err != nil {
+					return err
+				}
+				ok := secret != nil && secret.Labels[SecretLabelKey] == puller.Name
+				owned = &ok
+			}
+			if !*owned {
+				continue
+			}
+		}
+		err := c.patchServiceAccount(ctx, client.ObjectKeyFromObject(&sa), func(got *corev1.ServiceAccount) ([]byte, error) {
+			managed := managedSecrets(got)
+			if hasImagePullSecret(got, name) && managed.Has(name) {
+				return nil, nil
 			}
-			return nil
+			managed.Insert(name)
+			return serviceAccountPatch(got, managed, []string{name}, nil)
 		})
 		if err != nil {
 			errs = append(errs, err)
@@ -175,105 +419,304 @@ func (c *Controller) ensurerServiceAccount(ctx context.Context, namespace string
 	return utilerrors.NewAggregate(errs)
 }
 
-func newDockerSecret(name string, registries []pullerv1alpha1.Registry) (*corev1.Secret, error) {
-	content, err := buildDockerConfigJSON(registries)
+func encodeDockerConfigFieldAuth(username, password string) string {
+	fieldValue := username + ":" + password
+	return base64.StdEncoding.EncodeToString([]byte(fieldValue))
+}
+
+func decodeDockerConfigFieldAuth(auth string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(auth)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("invalid auth: %w", err)
 	}
-	return &corev1.Secret{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: corev1.SchemeGroupVersion.String(),
-			Kind:       "Secret",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
-			Labels: map[string]string{
-				SecretLabelKey: name,
-			},
-		},
-		Type: corev1.SecretTypeDockerConfigJson,
-		Data: map[string][]byte{
-			corev1.DockerConfigJsonKey: content,
-		},
-	}, nil
+	return string(decoded), nil
 }
 
-func encodeDockerConfigFieldAuth(username, password string) string {
-	fieldValue := username + ":" + password
-	return base64.StdEncoding.EncodeToString([]byte(fieldValue))
+// dockerConfigEntry is the entry of a registry in the auths of a docker config.
+type dockerConfigEntry struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	Email         string `json:"email,omitempty"`
+	Auth          string `json:"auth,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+	RegistryToken string `json:"registrytoken,omitempty"`
 }
 
-func buildDockerConfigJSON(registries []pullerv1alpha1.Registry) ([]byte, error) {
-	data := make(map[string]pullerv1alpha1.Registry)
-	for _, r := range registries {
-		reg := pullerv1alpha1.Registry{
-			Username: r.Username,
-			Password: r.Password,
-			Email:    r.Email,
-			Auth:     r.Auth,
+// dockerConfigAuths are the auths of a docker config, marshalled from the
+// most specific key to the least specific one, so that the readers which
+// take the first match use the most specific credentials.
+type dockerConfigAuths map[string]dockerConfigEntry
+
+func (a dockerConfigAuths) MarshalJSON() ([]byte, error) {
+	keys := make([]string, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return registry.MoreSpecific(keys[i], keys[j])
+	})
+	buf := bytes.NewBufferString("{")
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
 		}
-		if len(reg.Auth) == 0 {
-			reg.Auth = encodeDockerConfigFieldAuth(r.Username, r.Password)
+		v, err := json.Marshal(a[key])
+		if err != nil {
+			return nil, err
 		}
-		data[r.Server] = reg
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
 	}
-	content, err := json.Marshal(map[string]map[string]pullerv1alpha1.Registry{
-		"auths": data,
-	})
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// buildDockerConfigJSON builds the docker config of the registries, their
+// credentials must be resolved.
+func buildDockerConfigJSON(registries []pullerv1beta1.Registry) ([]byte, error) {
+	data, err := buildDockerConfigAuths(registries)
 	if err != nil {
 		return nil, err
 	}
-	return content, nil
+	return json.Marshal(map[string]dockerConfigAuths{
+		"auths": data,
+	})
+}
+
+// buildDockerConfigAuths builds the auths of the docker config of the
+// registries, the legacy .dockercfg format holds them alone.
+func buildDockerConfigAuths(registries []pullerv1beta1.Registry) (dockerConfigAuths, error) {
+	data := make(dockerConfigAuths)
+	for _, r := range registries {
+		var entry dockerConfigEntry
+		switch c := r.Credentials; {
+		case c.Basic != nil:
+			entry = dockerConfigEntry{
+				Username: c.Basic.Username,
+				Password: c.Basic.Password,
+				Email:    c.Basic.Email,
+				Auth:     c.Basic.Auth,
+			}
+		case c.Token != nil:
+			entry = dockerConfigEntry{
+				Username:      c.Token.Username,
+				IdentityToken: c.Token.IdentityToken,
+				RegistryToken: c.Token.RegistryToken,
+			}
+		default:
+			return nil, fmt.Errorf("credentials of registry %s are not resolved", r.Server)
+		}
+		// the auth of an empty username authenticates nobody
+		if len(entry.Auth) == 0 && len(entry.Username) != 0 {
+			entry.Auth = encodeDockerConfigFieldAuth(entry.Username, entry.Password)
+		}
+		// the kubelet matches the images against the canonical keys, two
+		// servers with the same key would silently override each other
+		key := registry.ConfigKey(r.Server)
+		if _, ok := data[key]; ok {
+			return nil, fmt.Errorf("registry %s duplicates the credentials of %s", r.Server, key)
+		}
+		data[key] = entry
+	}
+	return data, nil
 }
 
-func (c *Controller) syncPuller(ctx context.Context, puller *pullerv1alpha1.Puller) (ctrl.Result, error) {
+func (c *Controller) syncPuller(ctx context.Context, puller *pullerv1beta1.Puller) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
-	var (
-		err    error
-		nsList *corev1.NamespaceList
-	)
-	if puller.Spec.NamespaceAffinity == nil {
-		nsList, err = c.KubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
-		if err != nil {
-			logger.Error(err, "failed to list all namespace")
+	if puller.Spec.Suspend {
+		logger.V(4).Info("Puller is suspended, skip distribution", "name", puller.Name)
+		newStatus := puller.Status.DeepCopy()
+		SetSuspendedCondition(newStatus, "Suspended", "puller distribution is suspended")
+		if err := c.updateStatusIfNeed(ctx, puller, *newStatus); err != nil {
 			return ctrl.Result{Requeue: true}, err
 		}
-	} else {
-		nsList, err = c.KubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
-			LabelSelector: puller.Spec.NamespaceAffinity.String(),
-		})
-		if err != nil {
-			logger.Error(err, "failed to list namespace")
+		return ctrl.Result{}, nil
+	}
+	if errs := validation.ValidatePuller(puller); len(errs) != 0 {
+		// the webhook rejects such pullers, unless it is not deployed or
+		// the puller predates it
+		msg := errs.ToAggregate().Error()
+		logger.V(4).Info("Puller is invalid, skip distribution", "name", puller.Name, "errors", msg)
+		c.EventRecorder.Event(puller, corev1.EventTypeWarning, "InvalidSpec", msg)
+		newStatus := puller.Status.DeepCopy()
+		SetNotReadyCondition(newStatus, "InvalidSpec", "puller spec is invalid")
+		SetErrorCondition(newStatus, "InvalidSpec", msg)
+		if err := c.updateStatusIfNeed(ctx, puller, *newStatus); err != nil {
+			return ctrl.Result{Requeue: true}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	rendered, err := c.renderPuller(ctx, puller)
+	if err != nil {
+		logger.Error(err, "failed to resolve credentials")
+		newStatus := puller.Status.DeepCopy()
+		SetReadyUnknownCondition(newStatus, "Error", "puller reconcile error")
+		SetErrorCondition(newStatus, "CredentialsUnavailable", err.Error())
+		if err := c.updateStatusIfNeed(ctx, puller, *newStatus); err != nil {
 			return ctrl.Result{Requeue: true}, err
 		}
+		return ctrl.Result{Requeue: true}, err
 	}
+	rendered, missing := withoutMissingCredentials(rendered)
 
-	var errs []error
-	for _, ns := range nsList.Items {
-		secret, err := newDockerSecret(puller.Name, puller.Spec.Registries)
+	nsList, err := c.listNamespaces(ctx)
+	if err != nil {
+		logger.Error(err, "failed to list namespace")
+		return ctrl.Result{Requeue: true}, err
+	}
+	targetedNs := make([]corev1.Namespace, 0, len(nsList))
+	for _, ns := range nsList {
+		ok, err := targetsNamespace(puller, &ns)
 		if err != nil {
-			errs = append(errs, err)
-			continue
+			logger.Error(err, "failed to parse namespace affinity")
+			return ctrl.Result{Requeue: true}, err
 		}
-		secret.SetNamespace(ns.Name)
-		if err := controllerutil.SetOwnerReference(puller, secret, c.Scheme); err != nil {
-			errs = append(errs, err)
-			continue
+		if ok {
+			targetedNs = append(targetedNs, ns)
 		}
-		if err := c.ensureSecret(ctx, secret); err != nil {
+	}
+	targeted := make([]string, 0, len(targetedNs))
+	for _, ns := range targetedNs {
+		targeted = append(targeted, ns.Name)
+	}
+
+	planning := c.DryRun || puller.Spec.Mode == pullerv1beta1.ModePlan
+	namespaces := append([]string(nil), targeted...)
+	var (
+		errs     []error
+		rollout  *rolloutStep
+		revision = puller.Status.Revision
+	)
+	if !planning {
+		if revision, err = c.recordRevision(ctx, puller, rendered); err != nil {
+			logger.Error(err, "failed to record the revision of the credentials")
 			errs = append(errs, err)
-			continue
+			revision = puller.Status.Revision
+		}
+	}
+	// the credentials out of their validity window are not distributed
+	rendered, validity, validityChange := checkValidity(puller, rendered, time.Now())
+	if !planning {
+		if rollout, err = c.rollout(ctx, puller, rendered, targetedNs); err != nil {
+			logger.Error(err, "failed to plan the rollout")
+			return ctrl.Result{Requeue: true}, err
+		}
+		namespaces = rollout.namespaces
+	}
+
+	var (
+		blocked  []string
+		changes  []pullerv1beta1.PlannedChange
+		progress *pullerv1beta1.Progress
+	)
+	var results []fanOutResult
+	if planning {
+		sort.Strings(namespaces)
+		results, err = c.fanOut(ctx, namespaces, func(ctx context.Context, ns string) ([]pullerv1beta1.PlannedChange, error) {
+			return c.planNamespace(ctx, rendered, ns)
+		}, nil)
+	} else {
+		// the referenced credentials change without a new generation
+		hash, err := c.rolloutRevision(rendered)
+		if err != nil {
+			return ctrl.Result{Requeue: true}, err
+		}
+		tracker := c.resumeProgress(puller, hash, namespaces)
+		// the blocked namespaces before the checkpoint are not synced again
+		blocked = append(blocked, tracker.snapshot().Blocked...)
+		namespaces = tracker.namespaces
+		results, err = c.fanOut(ctx, namespaces, func(ctx context.Context, ns string) ([]pullerv1beta1.PlannedChange, error) {
+			return nil, c.syncNamespace(ctx, rendered, ns)
+		}, func(i int, result fanOutResult) {
+			tracker.done(ctx, i, result)
+		})
+		progress = tracker.snapshot()
+		tracker.observe(puller)
+		c.state.recordSync(puller.Name, targeted)
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for i, result := range results {
+		changes = append(changes, result.changes...)
+		var conflictErr *secretConflictError
+		if errors.As(result.err, &conflictErr) {
+			blocked = append(blocked, namespaces[i])
+		} else if result.err != nil {
+			errs = append(errs, result.err)
 		}
-		if err := c.ensurerServiceAccount(ctx, ns.Name, puller.Name); err != nil {
+	}
+	if planning {
+		// the secrets the puller leaves behind are deleted by the rotation
+		// or the sweeper
+		stale, err := c.planStale(ctx, rendered, namespaces)
+		changes = append(changes, stale...)
+		if err != nil {
 			errs = append(errs, err)
 		}
 	}
 
 	newStatus := puller.Status.DeepCopy()
+	ClearSuspendedCondition(newStatus)
+	newStatus.TargetNamespaces = len(targeted)
+	newStatus.Revision = revision
+	c.reportValidity(puller, newStatus, validity)
+	if len(missing) != 0 {
+		msg := fmt.Sprintf("registries without credentials are not distributed: %s", strings.Join(missing, ","))
+		SetCredentialsMissingCondition(newStatus, "CredentialsMissing", msg)
+		c.EventRecorder.Event(puller, corev1.EventTypeWarning, "CredentialsMissing", msg)
+	} else {
+		ClearCredentialsMissingCondition(newStatus)
+	}
+	rollingOut := rollout != nil && rollout.status != nil && rollout.status.Phase != pullerv1beta1.RolloutPhaseComplete
+	if !planning && len(errs) == 0 && !rollingOut {
+		newStatus.ObservedGeneration = puller.Generation
+	}
+	if !planning {
+		newStatus.Rollout = rollout.status
+	}
+	if len(blocked) != 0 {
+		msg := fmt.Sprintf("secret %s is not managed by puller in namespaces: %s", strings.Join(secretNamesFor(puller), ","), strings.Join(blocked, ","))
+		SetSecretConflictCondition(newStatus, "UnmanagedSecret", msg)
+		c.EventRecorder.Event(puller, corev1.EventTypeWarning, "SecretConflict", msg)
+	} else {
+		ClearSecretConflictCondition(newStatus)
+	}
+	if planning {
+		newStatus.Plan = newPlan(puller, changes)
+	} else {
+		newStatus.Plan = nil
+	}
+	if !planning {
+		newStatus.Progress = nil
+		if len(errs) != 0 {
+			// keep the checkpoint, the retry resumes from it
+			newStatus.Progress = progress
+		}
+	}
 	if err := utilerrors.NewAggregate(errs); err != nil {
 		SetReadyUnknownCondition(newStatus, "Error", "puller reconcile error")
 		SetErrorCondition(newStatus, "ErrorSeen", err.Error())
+	} else if planning {
+		SetReadyUnknownCondition(newStatus, "Planned", "puller is in plan mode, no change is applied")
+		ClearErrorCondition(newStatus)
+	} else if len(blocked) != 0 {
+		SetNotReadyCondition(newStatus, "SecretConflict", "puller blocked by unmanaged secrets")
+		ClearErrorCondition(newStatus)
+	} else if rollingOut && rollout.status.Phase == pullerv1beta1.RolloutPhaseFailed {
+		SetNotReadyCondition(newStatus, "RolloutFailed", rollout.status.Message)
+		ClearErrorCondition(newStatus)
+	} else if rollingOut {
+		SetReadyUnknownCondition(newStatus, "RolloutProgressing", fmt.Sprintf("revision %s is rolled out to %d of %d namespaces",
+			rollout.status.Revision, rollout.status.UpdatedNamespaces, rollout.status.TotalNamespaces))
+		ClearErrorCondition(newStatus)
 	} else {
 		SetReadyCondition(newStatus, "Ready", "puller reconcile ready")
 		ClearErrorCondition(newStatus)
@@ -282,92 +725,263 @@ func (c *Controller) syncPuller(ctx context.Context, puller *pullerv1alpha1.Pull
 	if err := c.updateStatusIfNeed(ctx, puller, *newStatus); err != nil {
 		return ctrl.Result{Requeue: true}, err
 	}
+	if planning {
+		return ctrl.Result{}, nil
+	}
 
-	return c.ensureFinalizer(puller)
+	result, err := c.ensureFinalizer(puller)
+	if err != nil {
+		return result, err
+	}
+	// the superseded secrets are deleted by the sync after their grace period
+	next, err := c.nextRetirement(ctx, puller)
+	if err != nil {
+		return ctrl.Result{Requeue: true}, err
+	}
+	if next > 0 {
+		result.RequeueAfter = next
+	}
+	// the next stage of the rollout is started by the sync after its wait,
+	// and the credentials change state at the bounds of their validity
+	for _, wait := range []time.Duration{rollout.requeueAfter, validityChange} {
+		if wait > 0 && (result.RequeueAfter == 0 || wait < result.RequeueAfter) {
+			result.RequeueAfter = wait
+		}
+	}
+	c.state.recordRequeue(puller.Name, result.RequeueAfter)
+	return result, nil
 }
 
-func (c *Controller) cleanImageSecretName(ctx context.Context, puller *pullerv1alpha1.Puller) (ctrl.Result, error) {
-	saList, err := c.KubeClient.CoreV1().ServiceAccounts(corev1.NamespaceAll).List(ctx, metav1.ListOptions{})
-	if err != nil {
+func (c *Controller) cleanImageSecretName(ctx context.Context, puller *pullerv1beta1.Puller) (ctrl.Result, error) {
+	if c.DryRun {
+		// leave the cleanup to a controller that is allowed to write
+		log.FromContext(ctx).V(4).Info("Dry run, skip cleanup of puller", "name", puller.Name)
+		return ctrl.Result{}, nil
+	}
+	retain := puller.Spec.DeletionPolicy == pullerv1beta1.DeletionPolicyRetain
+
+	secretList := &corev1.SecretList{}
+	if err := c.Client.List(ctx, secretList, client.MatchingLabels{SecretLabelKey: puller.Name}); err != nil {
 		return ctrl.Result{Requeue: true}, err
 	}
+	names := sets.New[string](secretNamesFor(puller)...)
+	for _, secret := range secretList.Items {
+		names.Insert(secret.Name)
+	}
+
+	saKeys := sets.New[types.NamespacedName]()
+	for name := range names {
+		saList := &corev1.ServiceAccountList{}
+		if err := c.Client.List(ctx, saList, client.MatchingFields{ManagedSecretsIndex: name}); err != nil {
+			return ctrl.Result{Requeue: true}, err
+		}
+		for _, sa := range saList.Items {
+			saKeys.Insert(client.ObjectKeyFromObject(&sa))
+		}
+	}
 
 	var errs []error
-	for _, sa := range saList.Items {
-		found := false
-		for i, im := range sa.ImagePullSecrets {
-			if im.Name == puller.Name {
-				sa.ImagePullSecrets = append(sa.ImagePullSecrets[:i], sa.ImagePullSecrets[i+1:]...)
-				found = true
-				break
-			}
+	for key := range saKeys {
+		if err := c.releaseServiceAccount(ctx, key.Namespace, key.Name, names, retain); err != nil {
+			errs = append(errs, err)
 		}
-		if found {
-			err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
-				got, err := c.KubeClient.CoreV1().ServiceAccounts(sa.Namespace).Get(ctx, sa.Name, metav1.GetOptions{})
-				if err != nil && apierrors.IsNotFound(err) {
-					return nil
-				} else if err != nil {
-					return err
-				}
-				sa.SetResourceVersion(got.ResourceVersion)
-				_, err = c.KubeClient.CoreV1().ServiceAccounts(sa.Namespace).Update(ctx, &sa, metav1.UpdateOptions{})
-				if err != nil {
-					return err
-				}
-				return nil
+	}
+
+	for _, secret := range secretList.Items {
+		var err error
+		if err = c.waitForWrite(ctx); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if retain {
+			err = c.releaseSecret(ctx, puller, &secret)
+		} else {
+			err = c.KubeClient.CoreV1().Secrets(secret.Namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{
+				Preconditions: &metav1.Preconditions{UID: &secret.UID},
 			})
-			if err != nil {
-				errs = append(errs, err)
+			if apierrors.IsNotFound(err) {
+				err = nil
 			}
 		}
+		if err != nil {
+			errs = append(errs, err)
+		}
 	}
+
 	if len(errs) != 0 {
 		return ctrl.Result{Requeue: true}, utilerrors.NewAggregate(errs)
 	}
 	return c.removeFinalizer(puller)
 }
 
+// releaseServiceAccount drops the puller markers of the given secrets from a
+// service account, and removes their image pull secret references unless retain is set.
+func (c *Controller) releaseServiceAccount(ctx context.Context, namespace, name string, names sets.Set[string], retain bool) error {
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	return c.patchServiceAccount(ctx, key, func(got *corev1.ServiceAccount) ([]byte, error) {
+		managed := managedSecrets(got)
+		owned := managed.Intersection(names)
+		if owned.Len() == 0 {
+			return nil, nil
+		}
+		var remove []string
+		if !retain {
+			remove = sets.List(owned)
+		}
+		return serviceAccountPatch(got, managed.Difference(owned), nil, remove)
+	})
+}
+
+// releaseSecret removes the puller label and owner reference from a secret,
+// so it is neither managed nor garbage collected after the puller is gone.
+func (c *Controller) releaseSecret(ctx context.Context, puller *pullerv1beta1.Puller, secret *corev1.Secret) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{
+				SecretLabelKey: nil,
+			},
+			"ownerReferences": []interface{}{
+				map[string]interface{}{"uid": puller.UID, "$patch": "delete"},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.KubeClient.CoreV1().Secrets(secret.Namespace).Patch(ctx, secret.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{
+		FieldManager: FieldManager,
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// managedSecrets returns the image pull secrets added to the service account by puller.
+func managedSecrets(sa *corev1.ServiceAccount) sets.Set[string] {
+	managed := sets.New[string]()
+	for _, name := range strings.Split(sa.Annotations[ManagedSecretsAnnotationKey], ",") {
+		if len(name) != 0 {
+			managed.Insert(name)
+		}
+	}
+	return managed
+}
+
+// indexManagedSecrets indexes a service account by the image pull secrets
+// added by puller.
+func indexManagedSecrets(obj client.Object) []string {
+	sa, ok := obj.(*corev1.ServiceAccount)
+	if !ok {
+		return nil
+	}
+	return sets.List(managedSecrets(sa))
+}
+
+// namespaceWatcherFunc enqueues the namespace for the pullers whose affinity matches it.
 func (c *Controller) namespaceWatcherFunc(ctx context.Context, obj client.Object, limitingInterface workqueue.RateLimitingInterface) {
-	pullerList := pullerv1alpha1.PullerList{}
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return
+	}
+	pullerList := pullerv1beta1.PullerList{}
 	if err := c.Client.List(ctx, &pullerList); err != nil {
 		return
 	}
 	for _, puller := range pullerList.Items {
-		limitingInterface.Add(reconcile.Request{NamespacedName: types.NamespacedName{
-			Name:      puller.GetName(),
-			Namespace: puller.GetNamespace(),
-		}})
+		if ok, err := targetsNamespace(&puller, ns); err != nil || !ok {
+			continue
+		}
+		limitingInterface.Add(namespaceRequest(puller.GetName(), ns.GetName()))
 	}
 }
 
+// secretWatcherFunc enqueues the namespace of a deleted secret for the puller that distributed it.
 func (c *Controller) secretWatcherFunc(ctx context.Context, obj client.Object, limitingInterface workqueue.RateLimitingInterface) {
 	val, ok := obj.GetLabels()[SecretLabelKey]
 	if !ok {
 		return
 	}
-	puller := pullerv1alpha1.Puller{}
-	if err := c.Client.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: val}, &puller); err != nil {
+	limitingInterface.Add(namespaceRequest(val, obj.GetNamespace()))
+}
+
+// owns reports whether the replica reconciles the puller.
+func (c *Controller) owns(name string) bool {
+	return c.Sharder == nil || c.Sharder.Owns(name)
+}
+
+// enqueueShards enqueues the pullers of the shards acquired by the replica,
+// their events were skipped while another replica held the shards.
+func (c *Controller) enqueueShards(ctx context.Context, shards []int) {
+	acquired := sets.New[int](shards...)
+	pullerList := pullerv1beta1.PullerList{}
+	if err := c.Client.List(ctx, &pullerList); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list pullers of acquired shards")
 		return
 	}
-	limitingInterface.Add(reconcile.Request{NamespacedName: types.NamespacedName{
-		Name:      puller.GetName(),
-		Namespace: puller.GetNamespace(),
-	}})
+	// the controller may not be started yet, do not block the lease renewal
+	go func() {
+		for i := range pullerList.Items {
+			puller := &pullerList.Items[i]
+			if !acquired.Has(sharding.ShardFor(puller.Name, c.Sharder.Shards)) {
+				continue
+			}
+			select {
+			case c.shardEvents <- event.GenericEvent{Object: puller}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// ManagedSecretSelector selects the secrets distributed by puller, the secret
+// cache is restricted to them.
+func ManagedSecretSelector() labels.Selector {
+	req, err := labels.NewRequirement(SecretLabelKey, selection.Exists, nil)
+	utilruntime.Must(err)
+	return labels.NewSelector().Add(*req)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (c *Controller) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).For(&pullerv1alpha1.Puller{}).
-		Watches(&corev1.Namespace{}, &handler.Funcs{
+	err := mgr.GetFieldIndexer().IndexField(context.TODO(), &corev1.ServiceAccount{}, ManagedSecretsIndex, indexManagedSecrets)
+	if err != nil {
+		return err
+	}
+
+	blder := ctrl.NewControllerManagedBy(mgr).Named(pullerQueue).For(&pullerv1beta1.Puller{})
+	if c.Sharder != nil {
+		c.shardEvents = make(chan event.GenericEvent)
+		c.Sharder.OnAcquired = c.enqueueShards
+		blder = blder.WatchesRawSource(&source.Channel{Source: c.shardEvents}, &handler.EnqueueRequestForObject{})
+	}
+	if err := blder.Complete(c); err != nil {
+		return err
+	}
+
+	// namespace and secret events only concern a single namespace, they are
+	// handled by a second controller keyed by puller and namespace.
+	blder = ctrl.NewControllerManagedBy(mgr).Named(NamespaceControllerName)
+	if len(c.WatchNamespaces) == 0 {
+		// a fixed namespace set is not watched, the controller may not be
+		// allowed to list namespaces
+		blder = blder.Watches(&corev1.Namespace{}, &handler.Funcs{
 			CreateFunc: func(ctx context.Context, createEvent event.CreateEvent, limitingInterface workqueue.RateLimitingInterface) {
 				c.namespaceWatcherFunc(ctx, createEvent.Object, limitingInterface)
 			},
-		}).
-		Watches(&corev1.Secret{}, &handler.Funcs{
-			DeleteFunc: func(ctx context.Context, deleteEvent event.DeleteEvent, limitingInterface workqueue.RateLimitingInterface) {
-				c.secretWatcherFunc(ctx, deleteEvent.Object, limitingInterface)
+			UpdateFunc: func(ctx context.Context, updateEvent event.UpdateEvent, limitingInterface workqueue.RateLimitingInterface) {
+				if !equality.Semantic.DeepEqual(updateEvent.ObjectOld.GetLabels(), updateEvent.ObjectNew.GetLabels()) {
+					c.namespaceWatcherFunc(ctx, updateEvent.ObjectNew, limitingInterface)
+				}
 			},
-		}).
-		Complete(c)
+		})
+	}
+	return blder.Watches(&corev1.Secret{}, &handler.Funcs{
+		DeleteFunc: func(ctx context.Context, deleteEvent event.DeleteEvent, limitingInterface workqueue.RateLimitingInterface) {
+			c.secretWatcherFunc(ctx, deleteEvent.Object, limitingInterface)
+		},
+	}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: c.ConcurrentNamespaceSyncs}).
+		Complete(reconcile.Func(c.ReconcileNamespace))
 }
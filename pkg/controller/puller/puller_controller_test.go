@@ -0,0 +1,472 @@
+package puller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/scheme"
+)
+
+// newTestController builds a controller whose cache, API reader and writes
+// are served by fake clients holding the objects. The writes are not seen by
+// the reads.
+func newTestController(objs ...client.Object) *Controller {
+	runtimeObjs := make([]runtime.Object, 0, len(objs))
+	for _, obj := range objs {
+		if _, ok := obj.(*pullerv1beta1.Puller); !ok {
+			runtimeObjs = append(runtimeObjs, obj.DeepCopyObject())
+		}
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&pullerv1beta1.Puller{}).
+		WithIndex(&corev1.ServiceAccount{}, ManagedSecretsIndex, indexManagedSecrets).
+		Build()
+	return &Controller{
+		Client:        fakeClient,
+		APIReader:     fakeClient,
+		Scheme:        scheme.Scheme,
+		KubeClient:    kubefake.NewSimpleClientset(runtimeObjs...),
+		EventRecorder: record.NewFakeRecorder(100),
+	}
+}
+
+func TestDecideSecret(t *testing.T) {
+	desired := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pull",
+			Namespace:   "default",
+			Annotations: map[string]string{ContentHashAnnotationKey: "new"},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+	}
+	existing := func(mutate func(*corev1.Secret)) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "pull",
+				Namespace:   "default",
+				Labels:      map[string]string{},
+				Annotations: map[string]string{ContentHashAnnotationKey: "old"},
+			},
+			Type: corev1.SecretTypeDockerConfigJson,
+		}
+		mutate(secret)
+		return secret
+	}
+	managed := func(s *corev1.Secret) { s.Labels[SecretLabelKey] = "puller" }
+	ownedBy := func(name string) func(*corev1.Secret) {
+		return func(s *corev1.Secret) {
+			s.OwnerReferences = []metav1.OwnerReference{{
+				APIVersion: pullerv1beta1.SchemeGroupVersion.String(),
+				Kind:       "Puller",
+				Name:       name,
+				UID:        "other",
+			}}
+		}
+	}
+
+	tests := []struct {
+		name         string
+		policy       pullerv1beta1.ConflictPolicy
+		got          *corev1.Secret
+		want         secretOp
+		wantConflict bool
+	}{
+		{
+			name: "missing secret is created",
+			want: secretOpCreate,
+		},
+		{
+			name: "managed secret with the same content is kept",
+			got: existing(func(s *corev1.Secret) {
+				managed(s)
+				s.Annotations[ContentHashAnnotationKey] = "new"
+			}),
+			want: secretOpNone,
+		},
+		{
+			name: "managed secret with another content is updated",
+			got:  existing(managed),
+			want: secretOpUpdate,
+		},
+		{
+			name: "managed secret of another type is recreated",
+			got: existing(func(s *corev1.Secret) {
+				managed(s)
+				s.Type = corev1.SecretTypeOpaque
+			}),
+			want: secretOpRecreate,
+		},
+		{
+			name: "managed immutable secret with another content is recreated",
+			got: existing(func(s *corev1.Secret) {
+				managed(s)
+				immutable := true
+				s.Immutable = &immutable
+			}),
+			want: secretOpRecreate,
+		},
+		{
+			name:         "unmanaged secret conflicts by default",
+			got:          existing(func(*corev1.Secret) {}),
+			wantConflict: true,
+		},
+		{
+			name:   "unmanaged secret is adopted",
+			policy: pullerv1beta1.ConflictPolicyAdopt,
+			got:    existing(func(*corev1.Secret) {}),
+			want:   secretOpUpdate,
+		},
+		{
+			name:   "unmanaged secret of another type is not adopted",
+			policy: pullerv1beta1.ConflictPolicyAdopt,
+			got: existing(func(s *corev1.Secret) {
+				s.Type = corev1.SecretTypeOpaque
+			}),
+			wantConflict: true,
+		},
+		{
+			name:   "unmanaged secret is overwritten",
+			policy: pullerv1beta1.ConflictPolicyOverwrite,
+			got:    existing(func(*corev1.Secret) {}),
+			want:   secretOpRecreate,
+		},
+		{
+			name:   "secret labelled by another puller is not adopted",
+			policy: pullerv1beta1.ConflictPolicyAdopt,
+			got: existing(func(s *corev1.Secret) {
+				s.Labels[SecretLabelKey] = "other"
+			}),
+			wantConflict: true,
+		},
+		{
+			name:         "secret owned by another puller is not overwritten",
+			policy:       pullerv1beta1.ConflictPolicyOverwrite,
+			got:          existing(ownedBy("other")),
+			wantConflict: true,
+		},
+		{
+			name:   "secret of a puller recreated with the same name is adopted",
+			policy: pullerv1beta1.ConflictPolicyAdopt,
+			got: existing(func(s *corev1.Secret) {
+				managed(s)
+				ownedBy("puller")(s)
+			}),
+			want: secretOpUpdate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puller := &pullerv1beta1.Puller{
+				ObjectMeta: metav1.ObjectMeta{Name: "puller", UID: "uid"},
+				Spec:       pullerv1beta1.PullerSpec{ConflictPolicy: tt.policy},
+			}
+			op, err := decideSecret(puller, tt.got, desired)
+			var conflictErr *secretConflictError
+			if conflict := errors.As(err, &conflictErr); conflict != tt.wantConflict {
+				t.Fatalf("decideSecret() error = %v, want conflict %v", err, tt.wantConflict)
+			}
+			if !tt.wantConflict && err != nil {
+				t.Fatalf("decideSecret() unexpected error = %v", err)
+			}
+			if op != tt.want {
+				t.Errorf("decideSecret() = %v, want %v", op, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncPullerMissingCredentials(t *testing.T) {
+	ctx := context.Background()
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller", Finalizers: []string{FinalizerKey}},
+		Spec: pullerv1beta1.PullerSpec{
+			Registries: []pullerv1beta1.Registry{
+				{
+					Server: "release.daocloud.io",
+					Credentials: pullerv1beta1.RegistryCredentials{
+						Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "secret"},
+					},
+				},
+				// converted from a v1alpha1 registry without username
+				{Server: "harbor.corp"},
+			},
+		},
+	}
+	c := newTestController(puller)
+	// the outcome of the reconcile does not matter, only the status
+	_, _ = c.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: puller.Name}})
+
+	got := &pullerv1beta1.Puller{}
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: puller.Name}, got); err != nil {
+		t.Fatal(err)
+	}
+	if invalid := apimeta.FindStatusCondition(got.Status.Conditions, ConditionTypeError); invalid != nil && invalid.Status == metav1.ConditionTrue {
+		t.Errorf("Error condition = %v, want the puller distributed", invalid)
+	}
+	missing := apimeta.FindStatusCondition(got.Status.Conditions, ConditionTypeCredentialsMissing)
+	if missing == nil || missing.Status != metav1.ConditionTrue || !strings.Contains(missing.Message, "harbor.corp") {
+		t.Errorf("CredentialsMissing condition = %v, want true for harbor.corp", missing)
+	}
+
+	rendered, servers := withoutMissingCredentials(puller)
+	if len(servers) != 1 || servers[0] != "harbor.corp" {
+		t.Errorf("withoutMissingCredentials() servers = %v, want [harbor.corp]", servers)
+	}
+	data, err := buildDockerConfigJSON(rendered.Spec.Registries)
+	if err != nil {
+		t.Fatalf("buildDockerConfigJSON() error = %v", err)
+	}
+	var config struct {
+		Auths map[string]json.RawMessage `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := config.Auths["release.daocloud.io"]; !ok || len(config.Auths) != 1 {
+		t.Errorf("auths = %v, want release.daocloud.io alone", config.Auths)
+	}
+}
+
+func TestEnsureServiceAccountAdoptsReferences(t *testing.T) {
+	puller := &pullerv1beta1.Puller{ObjectMeta: metav1.ObjectMeta{Name: "pull"}}
+	secret := func(owner string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      "pull",
+			Namespace: "default",
+			Labels:    map[string]string{SecretLabelKey: owner},
+		}}
+	}
+	serviceAccount := func(name string, refs ...string) *corev1.ServiceAccount {
+		sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		for _, ref := range refs {
+			sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: ref})
+		}
+		return sa
+	}
+
+	tests := []struct {
+		name string
+		// owner labels the referenced secret
+		owner       string
+		wantAdopted bool
+	}{
+		{name: "secret of the puller", owner: "pull", wantAdopted: true},
+		{name: "secret of another puller", owner: "other", wantAdopted: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(secret(tt.owner), serviceAccount("default", "pull"), serviceAccount("builder"))
+			ctx := context.Background()
+			if err := c.ensurerServiceAccount(ctx, puller, "default", "pull"); err != nil {
+				t.Fatalf("ensurerServiceAccount() error = %v", err)
+			}
+
+			serviceAccounts := c.KubeClient.CoreV1().ServiceAccounts("default")
+			sa, err := serviceAccounts.Get(ctx, "default", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sa.Annotations[ManagedSecretsAnnotationKey] == "pull"; got != tt.wantAdopted {
+				t.Errorf("existing reference adopted = %v, want %v", got, tt.wantAdopted)
+			}
+			if len(sa.ImagePullSecrets) != 1 {
+				t.Errorf("existing reference duplicated, imagePullSecrets = %v", sa.ImagePullSecrets)
+			}
+
+			sa, err = serviceAccounts.Get(ctx, "builder", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !hasImagePullSecret(sa, "pull") || sa.Annotations[ManagedSecretsAnnotationKey] != "pull" {
+				t.Errorf("reference not added and recorded, service account = %+v", sa)
+			}
+		})
+	}
+}
+
+func TestCleanImageSecretName(t *testing.T) {
+	serviceAccount := func(namespace, name, managed string, refs ...string) *corev1.ServiceAccount {
+		sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		if managed != "" {
+			sa.Labels = map[string]string{ManagedLabelKey: "true"}
+			sa.Annotations = map[string]string{ManagedSecretsAnnotationKey: managed}
+		}
+		for _, ref := range refs {
+			sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: ref})
+		}
+		return sa
+	}
+	refs := func(sa *corev1.ServiceAccount) []string {
+		names := make([]string, 0, len(sa.ImagePullSecrets))
+		for _, ref := range sa.ImagePullSecrets {
+			names = append(names, ref.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name       string
+		policy     pullerv1beta1.DeletionPolicy
+		wantRefs   []string
+		wantSecret bool
+	}{
+		{name: "delete", policy: pullerv1beta1.DeletionPolicyDelete, wantRefs: []string{"foreign"}, wantSecret: false},
+		{name: "retain", policy: pullerv1beta1.DeletionPolicyRetain, wantRefs: []string{"pull", "foreign"}, wantSecret: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puller := &pullerv1beta1.Puller{
+				ObjectMeta: metav1.ObjectMeta{Name: "pull", UID: "uid", Finalizers: []string{FinalizerKey}},
+				Spec:       pullerv1beta1.PullerSpec{DeletionPolicy: tt.policy},
+			}
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name:      "pull",
+				Namespace: "a",
+				Labels:    map[string]string{SecretLabelKey: "pull"},
+			}}
+			c := newTestController(
+				puller, secret,
+				serviceAccount("a", "default", "pull", "pull", "foreign"),
+				// references the puller did not record are left alone
+				serviceAccount("a", "builder", "", "pull"),
+				// service accounts managing the secrets of another puller
+				serviceAccount("b", "default", "other", "other"),
+			)
+			ctx := context.Background()
+			if _, err := c.cleanImageSecretName(ctx, puller); err != nil {
+				t.Fatalf("cleanImageSecretName() error = %v", err)
+			}
+
+			serviceAccounts := c.KubeClient.CoreV1().ServiceAccounts
+			sa, err := serviceAccounts("a").Get(ctx, "default", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(refs(sa), ","); got != strings.Join(tt.wantRefs, ",") {
+				t.Errorf("imagePullSecrets = %v, want %v", got, tt.wantRefs)
+			}
+			if _, ok := sa.Annotations[ManagedSecretsAnnotationKey]; ok {
+				t.Errorf("managed secrets still recorded, annotations = %v", sa.Annotations)
+			}
+			for _, key := range []types.NamespacedName{{Namespace: "a", Name: "builder"}, {Namespace: "b", Name: "default"}} {
+				sa, err := serviceAccounts(key.Namespace).Get(ctx, key.Name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(sa.ImagePullSecrets) != 1 {
+					t.Errorf("service account %s not managing the secret was patched, imagePullSecrets = %v", key, sa.ImagePullSecrets)
+				}
+			}
+
+			got, err := c.KubeClient.CoreV1().Secrets("a").Get(ctx, "pull", metav1.GetOptions{})
+			if (err == nil) != tt.wantSecret {
+				t.Fatalf("secret kept = %v, want %v", err == nil, tt.wantSecret)
+			}
+			if tt.wantSecret && got.Labels[SecretLabelKey] != "" {
+				t.Errorf("retained secret not released, labels = %v", got.Labels)
+			}
+		})
+	}
+}
+
+func TestBuildDockerConfigAuthsToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		token *pullerv1beta1.TokenCredentials
+		want  string
+	}{
+		{
+			name:  "identity token",
+			token: &pullerv1beta1.TokenCredentials{IdentityToken: "refresh"},
+			want:  `{"harbor.corp":{"identitytoken":"refresh"}}`,
+		},
+		{
+			name:  "registry token",
+			token: &pullerv1beta1.TokenCredentials{RegistryToken: "bearer"},
+			want:  `{"harbor.corp":{"registrytoken":"bearer"}}`,
+		},
+		{
+			name:  "identity token with a username",
+			token: &pullerv1beta1.TokenCredentials{Username: "oauth2", IdentityToken: "refresh"},
+			want:  `{"harbor.corp":{"username":"oauth2","auth":"b2F1dGgyOg==","identitytoken":"refresh"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auths, err := buildDockerConfigAuths([]pullerv1beta1.Registry{{
+				Server:      "harbor.corp",
+				Credentials: pullerv1beta1.RegistryCredentials{Token: tt.token},
+			}})
+			if err != nil {
+				t.Fatalf("buildDockerConfigAuths() error = %v", err)
+			}
+			got, err := json.Marshal(auths)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("auths = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileSuspended(t *testing.T) {
+	ctx := context.Background()
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller", Finalizers: []string{FinalizerKey}},
+		Spec: pullerv1beta1.PullerSpec{
+			Suspend: true,
+			Registries: []pullerv1beta1.Registry{{
+				Server: "harbor.corp",
+				Credentials: pullerv1beta1.RegistryCredentials{
+					Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "rotated"},
+				},
+			}},
+		},
+	}
+	// the distributed secret is outdated and a namespace lacks it
+	c := newTestController(puller,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "puller",
+			Namespace:   "a",
+			Labels:      map[string]string{SecretLabelKey: puller.Name},
+			Annotations: map[string]string{ContentHashAnnotationKey: "outdated"},
+		}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "b"}},
+	)
+	if _, err := c.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: puller.Name}}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if actions := c.KubeClient.(*kubefake.Clientset).Actions(); len(actions) != 0 {
+		t.Errorf("wrote %d times while suspended, first %v", len(actions), actions[0])
+	}
+
+	got := &pullerv1beta1.Puller{}
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: puller.Name}, got); err != nil {
+		t.Fatal(err)
+	}
+	suspended := apimeta.FindStatusCondition(got.Status.Conditions, ConditionTypeSuspended)
+	if suspended == nil || suspended.Status != metav1.ConditionTrue {
+		t.Errorf("Suspended condition = %v, want true", suspended)
+	}
+}
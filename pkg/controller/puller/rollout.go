@@ -0,0 +1,244 @@
+package puller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/registry"
+	"github.com/puller-io/puller/pkg/tracing"
+)
+
+// registryCheckRetryPeriod is the interval between two registry checks of a
+// rollout that could not reach a registry.
+const registryCheckRetryPeriod = time.Minute
+
+// rolloutStep is the part of a staged rollout a sync applies.
+type rolloutStep struct {
+	// namespaces are the target namespaces the revision is applied to.
+	namespaces []string
+	// status is the rollout status after the sync, nil without strategy.
+	status *pullerv1beta1.RolloutStatus
+	// requeueAfter is the time until the next stage may start, zero if
+	// none is pending.
+	requeueAfter time.Duration
+}
+
+// rolloutRevision identifies the content of the secrets the puller
+// distributes, which does not depend on the namespace.
+func (c *Controller) rolloutRevision(puller *pullerv1beta1.Puller) (string, error) {
+	secrets, err := c.desiredSecrets(puller, "")
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, d := range secrets {
+		fmt.Fprintf(h, "%s=%s\n", d.secret.Name, d.secret.Annotations[ContentHashAnnotationKey])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:pullerv1beta1.VersionHashLength], nil
+}
+
+// rolloutStages returns the target namespaces reached at each stage of the
+// rollout: the canary namespaces, then for each batch the canary
+// namespaces and its percentage of the other ones, in lexical order.
+func rolloutStages(puller *pullerv1beta1.Puller, targeted []corev1.Namespace) ([][]string, error) {
+	canary, err := metav1.LabelSelectorAsSelector(puller.Spec.RolloutStrategy.Canary)
+	if err != nil {
+		return nil, err
+	}
+	var canaries, others []string
+	for i := range targeted {
+		if canary.Matches(labels.Set(targeted[i].Labels)) {
+			canaries = append(canaries, targeted[i].Name)
+		} else {
+			others = append(others, targeted[i].Name)
+		}
+	}
+	sort.Strings(others)
+
+	batches := pullerv1beta1.RolloutBatches(puller)
+	stages := make([][]string, 0, len(batches)+1)
+	stages = append(stages, canaries)
+	for _, percent := range batches {
+		n := (len(others)*int(percent) + 99) / 100
+		stage := make([]string, 0, len(canaries)+n)
+		stage = append(stage, canaries...)
+		stages = append(stages, append(stage, others[:n]...))
+	}
+	return stages, nil
+}
+
+// rollout decides which target namespaces the sync applies the rendered
+// secrets to. A new revision starts with the canary namespaces, and moves
+// to the next stage once the current one soaked and the registries accept
+// its credentials. The namespaces of the later stages are not synced, they
+// keep the secrets of the previous revision.
+func (c *Controller) rollout(ctx context.Context, puller, rendered *pullerv1beta1.Puller, targeted []corev1.Namespace) (*rolloutStep, error) {
+	all := make([]string, 0, len(targeted))
+	for _, ns := range targeted {
+		all = append(all, ns.Name)
+	}
+	if puller.Spec.RolloutStrategy == nil {
+		return &rolloutStep{namespaces: all}, nil
+	}
+
+	revision, err := c.rolloutRevision(rendered)
+	if err != nil {
+		return nil, err
+	}
+	now := metav1.Now()
+	status := puller.Status.Rollout.DeepCopy()
+	started := false
+	switch {
+	case status == nil:
+		// the strategy was just set, there is no rollout in progress to
+		// protect the namespaces from
+		status = &pullerv1beta1.RolloutStatus{Revision: revision, Phase: pullerv1beta1.RolloutPhaseComplete}
+	case status.Revision != revision:
+		status = &pullerv1beta1.RolloutStatus{
+			Revision:       revision,
+			Phase:          pullerv1beta1.RolloutPhaseProgressing,
+			StageStartTime: &now,
+		}
+		started = true
+		c.EventRecorder.Eventf(puller, corev1.EventTypeNormal, "RolloutStarted", "Rolling out revision %s to the canary namespaces", revision)
+	}
+
+	stages, err := rolloutStages(puller, targeted)
+	if err != nil {
+		return nil, err
+	}
+	last := int32(len(stages) - 1)
+	if status.Stage > last {
+		// the batches were changed during the rollout
+		status.Stage = last
+	}
+
+	step := &rolloutStep{status: status}
+	switch {
+	case status.Phase != pullerv1beta1.RolloutPhaseProgressing:
+	case started:
+		// the canary namespaces are synced before they soak
+		step.requeueAfter = pullerv1beta1.RolloutSoakDuration(puller)
+	default:
+		wait := pullerv1beta1.RolloutBatchInterval(puller)
+		if status.Stage == 0 {
+			wait = pullerv1beta1.RolloutSoakDuration(puller)
+		}
+		stageStart := now.Time
+		if status.StageStartTime != nil {
+			stageStart = status.StageStartTime.Time
+		}
+		// the current stage is over once it waited and its last sync had
+		// no error, the error of a failed sync requeues the puller
+		if remaining := wait - now.Sub(stageStart); remaining > 0 {
+			step.requeueAfter = remaining
+			break
+		}
+		if puller.Status.Progress != nil {
+			break
+		}
+		err := c.checkRegistries(ctx, rendered)
+		if registry.IsRejected(err) {
+			status.Phase = pullerv1beta1.RolloutPhaseFailed
+			status.Message = err.Error()
+			c.EventRecorder.Eventf(puller, corev1.EventTypeWarning, "RolloutFailed", "Stopped the rollout of revision %s: %v", revision, err)
+			break
+		} else if err != nil {
+			log.FromContext(ctx).Error(err, "failed to check the registries, retrying", "name", puller.Name)
+			status.Message = fmt.Sprintf("waiting for the registry check: %v", err)
+			step.requeueAfter = registryCheckRetryPeriod
+			break
+		}
+		status.Stage++
+		status.StageStartTime = &now
+		status.Message = ""
+		if status.Stage == last {
+			status.Phase = pullerv1beta1.RolloutPhaseComplete
+			c.EventRecorder.Eventf(puller, corev1.EventTypeNormal, "RolloutComplete", "Rolled out revision %s to every namespace", revision)
+		} else {
+			c.EventRecorder.Eventf(puller, corev1.EventTypeNormal, "RolloutProgressing", "Rolling out revision %s to batch %d", revision, status.Stage)
+			step.requeueAfter = pullerv1beta1.RolloutBatchInterval(puller)
+		}
+	}
+	if status.Phase == pullerv1beta1.RolloutPhaseProgressing && step.requeueAfter < time.Second {
+		// the stage is synced by this sync, the next one checks it
+		step.requeueAfter = time.Second
+	}
+
+	if status.Phase == pullerv1beta1.RolloutPhaseComplete {
+		step.namespaces = all
+		status.Stage = 0
+		status.StageStartTime = nil
+	} else {
+		step.namespaces = stages[status.Stage]
+	}
+	status.UpdatedNamespaces = len(step.namespaces)
+	status.TotalNamespaces = len(all)
+	return step, nil
+}
+
+// rolledOut reports whether the rendered secrets of the puller may be
+// applied to any target namespace, that is when the puller has no rollout
+// strategy or their revision is completely rolled out.
+func (c *Controller) rolledOut(puller, rendered *pullerv1beta1.Puller) (bool, error) {
+	if puller.Spec.RolloutStrategy == nil {
+		return true, nil
+	}
+	status := puller.Status.Rollout
+	if status == nil || status.Phase != pullerv1beta1.RolloutPhaseComplete {
+		return false, nil
+	}
+	revision, err := c.rolloutRevision(rendered)
+	if err != nil {
+		return false, err
+	}
+	return status.Revision == revision, nil
+}
+
+// checkRegistries checks the credentials of the rendered puller against its
+// registries, a rejection by any of them is returned over the errors to
+// reach the others.
+func (c *Controller) checkRegistries(ctx context.Context, rendered *pullerv1beta1.Puller) error {
+	if c.RegistryChecker == nil {
+		return nil
+	}
+	var errs []error
+	for _, r := range rendered.Spec.Registries {
+		var creds registry.Credentials
+		if t := r.Credentials.Token; t != nil {
+			creds = registry.Credentials{Username: t.Username, IdentityToken: t.IdentityToken, RegistryToken: t.RegistryToken}
+		} else {
+			_, username, password, err := basicCredentialsOf(rendered, r.Server)
+			if err != nil {
+				return err
+			}
+			creds = registry.Credentials{Username: username, Password: password}
+		}
+		err := c.checkRegistry(ctx, r.Server, creds)
+		if registry.IsRejected(err) {
+			return err
+		} else if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// checkRegistry checks the credentials against a single registry.
+func (c *Controller) checkRegistry(ctx context.Context, server string, creds registry.Credentials) (err error) {
+	ctx, span := tracing.Start(ctx, "checkRegistry", attribute.String("registry", server))
+	defer func() { tracing.End(span, err) }()
+	return c.RegistryChecker.Check(ctx, server, creds)
+}
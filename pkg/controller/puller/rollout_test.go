@@ -0,0 +1,156 @@
+package puller
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+func TestRolloutStages(t *testing.T) {
+	namespace := func(name string, canary bool) corev1.Namespace {
+		ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if canary {
+			ns.Labels = map[string]string{"canary": "true"}
+		}
+		return ns
+	}
+	targeted := []corev1.Namespace{
+		namespace("e", false),
+		namespace("canary", true),
+		namespace("a", false),
+		namespace("d", false),
+		namespace("c", false),
+		namespace("b", false),
+	}
+	canary := &metav1.LabelSelector{MatchLabels: map[string]string{"canary": "true"}}
+
+	tests := []struct {
+		name     string
+		strategy *pullerv1beta1.RolloutStrategy
+		targeted []corev1.Namespace
+		want     [][]string
+	}{
+		{
+			name:     "canaries then every namespace by default",
+			strategy: &pullerv1beta1.RolloutStrategy{Canary: canary},
+			targeted: targeted,
+			want: [][]string{
+				{"canary"},
+				{"canary", "a", "b", "c", "d", "e"},
+			},
+		},
+		{
+			name:     "batches round up in lexical order",
+			strategy: &pullerv1beta1.RolloutStrategy{Canary: canary, Batches: []int32{10, 50, 100}},
+			targeted: targeted,
+			want: [][]string{
+				{"canary"},
+				{"canary", "a"},
+				{"canary", "a", "b", "c"},
+				{"canary", "a", "b", "c", "d", "e"},
+			},
+		},
+		{
+			name:     "no canary namespace",
+			strategy: &pullerv1beta1.RolloutStrategy{Canary: canary, Batches: []int32{50, 100}},
+			targeted: targeted[2:4],
+			want: [][]string{
+				nil,
+				{"a"},
+				{"a", "d"},
+			},
+		},
+		{
+			name:     "no target namespace",
+			strategy: &pullerv1beta1.RolloutStrategy{Canary: canary},
+			want:     [][]string{nil, {}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puller := &pullerv1beta1.Puller{Spec: pullerv1beta1.PullerSpec{RolloutStrategy: tt.strategy}}
+			got, err := rolloutStages(puller, tt.targeted)
+			if err != nil {
+				t.Fatalf("rolloutStages() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rolloutStages() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRolledOut(t *testing.T) {
+	c := newTestController()
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller"},
+		Spec: pullerv1beta1.PullerSpec{
+			Registries: []pullerv1beta1.Registry{{
+				Server: "harbor.corp",
+				Credentials: pullerv1beta1.RegistryCredentials{
+					Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "secret"},
+				},
+			}},
+		},
+	}
+	revision, err := c.rolloutRevision(puller)
+	if err != nil {
+		t.Fatalf("rolloutRevision() error = %v", err)
+	}
+	strategy := &pullerv1beta1.RolloutStrategy{Canary: &metav1.LabelSelector{}}
+
+	tests := []struct {
+		name     string
+		strategy *pullerv1beta1.RolloutStrategy
+		rollout  *pullerv1beta1.RolloutStatus
+		want     bool
+	}{
+		{
+			name: "no rollout strategy",
+			want: true,
+		},
+		{
+			name:     "rollout not started",
+			strategy: strategy,
+		},
+		{
+			name:     "revision rolled out",
+			strategy: strategy,
+			rollout:  &pullerv1beta1.RolloutStatus{Revision: revision, Phase: pullerv1beta1.RolloutPhaseComplete},
+			want:     true,
+		},
+		{
+			name:     "revision rolling out",
+			strategy: strategy,
+			rollout:  &pullerv1beta1.RolloutStatus{Revision: revision, Phase: pullerv1beta1.RolloutPhaseProgressing},
+		},
+		{
+			name:     "revision failed",
+			strategy: strategy,
+			rollout:  &pullerv1beta1.RolloutStatus{Revision: revision, Phase: pullerv1beta1.RolloutPhaseFailed},
+		},
+		{
+			name:     "another revision rolled out",
+			strategy: strategy,
+			rollout:  &pullerv1beta1.RolloutStatus{Revision: "previous", Phase: pullerv1beta1.RolloutPhaseComplete},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := puller.DeepCopy()
+			p.Spec.RolloutStrategy = tt.strategy
+			p.Status.Rollout = tt.rollout
+			got, err := c.rolledOut(p, p)
+			if err != nil {
+				t.Fatalf("rolledOut() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("rolledOut() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
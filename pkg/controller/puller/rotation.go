@@ -0,0 +1,171 @@
+package puller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+// retireVersions releases the previous versions of the image pull secret of
+// an output from the service accounts of the namespace, marks them
+// superseded, and deletes them once the grace period of the rotation is
+// over. The versions are the immutable secrets of the output, and the
+// secret named after the output when current is a version.
+func (c *Controller) retireVersions(ctx context.Context, puller *pullerv1beta1.Puller, namespace, output, current string) error {
+	secretList := &corev1.SecretList{}
+	if err := c.Client.List(ctx, secretList, client.InNamespace(namespace),
+		client.MatchingLabels{SecretLabelKey: puller.Name, OutputLabelKey: output}); err != nil {
+		return err
+	}
+	previous := make([]*corev1.Secret, 0, len(secretList.Items))
+	for i := range secretList.Items {
+		if secretList.Items[i].Name != current {
+			previous = append(previous, &secretList.Items[i])
+		}
+	}
+	if current != output {
+		got, err := c.getSecret(ctx, namespace, output)
+		if err != nil {
+			return err
+		}
+		if got != nil && got.Labels[SecretLabelKey] == puller.Name {
+			previous = append(previous, got)
+		}
+	}
+
+	var errs []error
+	for _, secret := range previous {
+		if err := c.retireVersion(ctx, puller, secret); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *Controller) retireVersion(ctx context.Context, puller *pullerv1beta1.Puller, secret *corev1.Secret) error {
+	// the new pods reference the new version from now on
+	saList := &corev1.ServiceAccountList{}
+	if err := c.Client.List(ctx, saList, client.InNamespace(secret.Namespace), client.MatchingFields{ManagedSecretsIndex: secret.Name}); err != nil {
+		return err
+	}
+	for _, sa := range saList.Items {
+		if err := c.releaseServiceAccount(ctx, sa.Namespace, sa.Name, sets.New[string](secret.Name), false); err != nil {
+			return err
+		}
+	}
+
+	supersededAt, err := time.Parse(time.RFC3339, secret.Annotations[SupersededAtAnnotationKey])
+	if err != nil {
+		// not superseded yet, or by a broken annotation that starts over
+		return c.patchSupersededAt(ctx, secret, time.Now().UTC().Format(time.RFC3339))
+	}
+	if time.Since(supersededAt) < pullerv1beta1.RotationGracePeriod(puller) {
+		return nil
+	}
+
+	log.FromContext(ctx).V(4).Info("Deleting superseded secret", "namespace", secret.Namespace, "name", secret.Name)
+	if err := c.waitForWrite(ctx); err != nil {
+		return err
+	}
+	err = c.KubeClient.CoreV1().Secrets(secret.Namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &secret.UID},
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// distributedSecret returns the secret of an output distributed to the
+// namespace, nil if there is none. For an immutable image pull secret, it is
+// the newest version that is not superseded. It only reads from the cache,
+// a secret puller does not manage is not distributed.
+func (c *Controller) distributedSecret(ctx context.Context, puller *pullerv1beta1.Puller, namespace string, output pullerv1beta1.Output) (*corev1.Secret, error) {
+	if !pullerv1beta1.ImmutableSecrets(puller) || !output.Format.IsPullSecret() {
+		secret := &corev1.Secret{}
+		err := c.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: output.Name}, secret)
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return secret, nil
+	}
+	secretList := &corev1.SecretList{}
+	if err := c.Client.List(ctx, secretList, client.InNamespace(namespace),
+		client.MatchingLabels{SecretLabelKey: puller.Name, OutputLabelKey: output.Name}); err != nil {
+		return nil, err
+	}
+	var current *corev1.Secret
+	for i := range secretList.Items {
+		secret := &secretList.Items[i]
+		if secret.Annotations[SupersededAtAnnotationKey] != "" {
+			continue
+		}
+		if current == nil || current.CreationTimestamp.Before(&secret.CreationTimestamp) {
+			current = secret
+		}
+	}
+	return current, nil
+}
+
+// patchSupersededAt sets the superseded annotation of a secret, nil removes it.
+func (c *Controller) patchSupersededAt(ctx context.Context, secret *corev1.Secret, value interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				SupersededAtAnnotationKey: value,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.waitForWrite(ctx); err != nil {
+		return err
+	}
+	_, err = c.KubeClient.CoreV1().Secrets(secret.Namespace).Patch(ctx, secret.Name, types.MergePatchType, patch, metav1.PatchOptions{
+		FieldManager: FieldManager,
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// nextRetirement returns the time until the grace period of the next
+// superseded secret of the puller is over, zero if there is none.
+func (c *Controller) nextRetirement(ctx context.Context, puller *pullerv1beta1.Puller) (time.Duration, error) {
+	secretList := &corev1.SecretList{}
+	if err := c.Client.List(ctx, secretList, client.MatchingLabels{SecretLabelKey: puller.Name}); err != nil {
+		return 0, err
+	}
+	grace := pullerv1beta1.RotationGracePeriod(puller)
+	var next time.Duration
+	for _, secret := range secretList.Items {
+		supersededAt, err := time.Parse(time.RFC3339, secret.Annotations[SupersededAtAnnotationKey])
+		if err != nil {
+			continue
+		}
+		// the annotation has a second precision
+		remaining := time.Until(supersededAt.Add(grace)) + time.Second
+		if remaining < time.Second {
+			remaining = time.Second
+		}
+		if next == 0 || remaining < next {
+			next = remaining
+		}
+	}
+	return next, nil
+}
@@ -0,0 +1,113 @@
+package puller
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/tracing"
+)
+
+// traceCollector is an in-process OTLP trace collector keeping the spans it
+// receives.
+type traceCollector struct {
+	collectortracev1.UnimplementedTraceServiceServer
+
+	mu    sync.Mutex
+	spans []*tracev1.Span
+}
+
+func (c *traceCollector) Export(_ context.Context, req *collectortracev1.ExportTraceServiceRequest) (*collectortracev1.ExportTraceServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			c.spans = append(c.spans, ss.GetSpans()...)
+		}
+	}
+	return &collectortracev1.ExportTraceServiceResponse{}, nil
+}
+
+// startTraceCollector serves a trace collector on a local port until the
+// test ends, and returns it with its address.
+func startTraceCollector(t *testing.T) (*traceCollector, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := &traceCollector{}
+	server := grpc.NewServer()
+	collectortracev1.RegisterTraceServiceServer(server, collector)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+	return collector, listener.Addr().String()
+}
+
+func TestReconcileSpans(t *testing.T) {
+	collector, endpoint := startTraceCollector(t)
+	ctx := context.Background()
+	shutdown, err := tracing.Setup(ctx, tracing.Config{Endpoint: endpoint, Insecure: true, SamplingRatio: 1})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	t.Cleanup(func() { otel.SetTracerProvider(trace.NewNoopTracerProvider()) })
+
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller", Finalizers: []string{FinalizerKey}},
+		Spec: pullerv1beta1.PullerSpec{
+			Registries: []pullerv1beta1.Registry{{
+				Server: "registry.example.com",
+				Credentials: pullerv1beta1.RegistryCredentials{
+					Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "password"},
+				},
+			}},
+		},
+	}
+	c := newTestController(puller, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+	c.FanOutWorkers = 1
+	// the outcome of the reconcile does not matter, only its spans
+	_, _ = c.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: puller.Name}})
+	if err := shutdown(ctx); err != nil {
+		t.Fatalf("failed to flush the spans: %v", err)
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	byName := map[string]*tracev1.Span{}
+	for _, span := range collector.spans {
+		byName[span.GetName()] = span
+	}
+	reconcile, ok := byName["Reconcile"]
+	if !ok {
+		t.Fatalf("no Reconcile span among %d spans", len(collector.spans))
+	}
+	if len(reconcile.GetParentSpanId()) != 0 {
+		t.Errorf("Reconcile span has a parent, want a root span")
+	}
+	for name, parent := range map[string]*tracev1.Span{"syncNamespace": reconcile, "ensureSecret": byName["syncNamespace"]} {
+		span, ok := byName[name]
+		if !ok {
+			t.Errorf("no %s span", name)
+			continue
+		}
+		if string(span.GetTraceId()) != string(reconcile.GetTraceId()) {
+			t.Errorf("%s span is not in the trace of the reconcile", name)
+		}
+		if parent != nil && string(span.GetParentSpanId()) != string(parent.GetSpanId()) {
+			t.Errorf("%s span is not a child of the %s span", name, parent.GetName())
+		}
+	}
+}
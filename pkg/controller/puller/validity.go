@@ -0,0 +1,147 @@
+package puller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/registry"
+)
+
+// validityOf returns the validity window of the credentials of a resolved
+// registry, a zero time for an open bound. The bounds of the spec take
+// precedence over the claims of a JWT credential.
+func validityOf(r *pullerv1beta1.Registry) (validFrom, expiresAt time.Time) {
+	var tokens []string
+	if b := r.Credentials.Basic; b != nil {
+		tokens = append(tokens, b.Password)
+		if b.Auth != "" {
+			if decoded, err := decodeDockerConfigFieldAuth(b.Auth); err == nil {
+				_, password, _ := strings.Cut(decoded, ":")
+				tokens = append(tokens, password)
+			}
+		}
+	}
+	if t := r.Credentials.Token; t != nil {
+		tokens = append(tokens, t.IdentityToken, t.RegistryToken)
+	}
+	for _, token := range tokens {
+		if notBefore, notAfter, ok := registry.TokenValidity(token); ok {
+			validFrom, expiresAt = notBefore, notAfter
+			break
+		}
+	}
+	if r.ValidFrom != nil {
+		validFrom = r.ValidFrom.Time
+	}
+	if r.ExpiresAt != nil {
+		expiresAt = r.ExpiresAt.Time
+	}
+	return validFrom, expiresAt
+}
+
+// checkValidity returns a copy of the rendered puller without the registries
+// whose credentials are out of their validity window, the validity of the
+// registries that have one, and the time until the next change of their
+// state, zero if none is known.
+func checkValidity(puller, rendered *pullerv1beta1.Puller, now time.Time) (*pullerv1beta1.Puller, []pullerv1beta1.RegistryStatus, time.Duration) {
+	warnings := pullerv1beta1.ExpiryWarnings(puller)
+	valid := rendered.DeepCopy()
+	valid.Spec.Registries = valid.Spec.Registries[:0]
+	var (
+		statuses []pullerv1beta1.RegistryStatus
+		next     time.Duration
+	)
+	changesIn := func(d time.Duration) {
+		if d > 0 && (next == 0 || d < next) {
+			next = d
+		}
+	}
+	for i := range rendered.Spec.Registries {
+		r := &rendered.Spec.Registries[i]
+		validFrom, expiresAt := validityOf(r)
+		if validFrom.IsZero() && expiresAt.IsZero() {
+			valid.Spec.Registries = append(valid.Spec.Registries, *r.DeepCopy())
+			continue
+		}
+
+		status := pullerv1beta1.RegistryStatus{Server: r.Server, State: pullerv1beta1.CredentialsStateValid}
+		if !validFrom.IsZero() {
+			status.ValidFrom = &metav1.Time{Time: validFrom}
+		}
+		if !expiresAt.IsZero() {
+			status.ExpiresAt = &metav1.Time{Time: expiresAt}
+		}
+		switch {
+		case !validFrom.IsZero() && now.Before(validFrom):
+			status.State = pullerv1beta1.CredentialsStateNotYetValid
+			changesIn(validFrom.Sub(now))
+		case !expiresAt.IsZero() && !now.Before(expiresAt):
+			status.State = pullerv1beta1.CredentialsStateExpired
+		case !expiresAt.IsZero():
+			remaining := expiresAt.Sub(now)
+			changesIn(remaining)
+			for _, w := range warnings {
+				if remaining <= w {
+					status.State = pullerv1beta1.CredentialsStateExpiringSoon
+					status.Warning = &metav1.Duration{Duration: w}
+				} else {
+					changesIn(remaining - w)
+				}
+			}
+		}
+		statuses = append(statuses, status)
+		if status.State == pullerv1beta1.CredentialsStateValid || status.State == pullerv1beta1.CredentialsStateExpiringSoon {
+			valid.Spec.Registries = append(valid.Spec.Registries, *r.DeepCopy())
+		}
+	}
+	return valid, statuses, next
+}
+
+// reportValidity records the warning events of the credentials that expired
+// or crossed an expiry warning since the previous status, and sets the
+// ExpiringSoon condition.
+func (c *Controller) reportValidity(puller *pullerv1beta1.Puller, newStatus *pullerv1beta1.PullerStatus, statuses []pullerv1beta1.RegistryStatus) {
+	previous := make(map[string]pullerv1beta1.RegistryStatus, len(puller.Status.Registries))
+	for _, s := range puller.Status.Registries {
+		previous[s.Server] = s
+	}
+	var expiring, expired []string
+	for _, s := range statuses {
+		prev, seen := previous[s.Server]
+		switch s.State {
+		case pullerv1beta1.CredentialsStateExpired:
+			expired = append(expired, s.Server)
+			if !seen || prev.State != s.State {
+				c.EventRecorder.Eventf(puller, corev1.EventTypeWarning, "CredentialsExpired",
+					"Credentials of registry %s expired at %s, they are not distributed anymore", s.Server, s.ExpiresAt.UTC().Format(time.RFC3339))
+			}
+		case pullerv1beta1.CredentialsStateExpiringSoon:
+			expiring = append(expiring, fmt.Sprintf("%s at %s", s.Server, s.ExpiresAt.UTC().Format(time.RFC3339)))
+			if !seen || prev.Warning == nil || prev.Warning.Duration != s.Warning.Duration {
+				c.EventRecorder.Eventf(puller, corev1.EventTypeWarning, "CredentialsExpiringSoon",
+					"Credentials of registry %s expire at %s, in less than %s", s.Server, s.ExpiresAt.UTC().Format(time.RFC3339), s.Warning.Duration)
+			}
+		}
+	}
+	newStatus.Registries = statuses
+	var parts []string
+	if len(expired) != 0 {
+		parts = append(parts, "expired: "+strings.Join(expired, ", "))
+	}
+	if len(expiring) != 0 {
+		parts = append(parts, "expiring: "+strings.Join(expiring, ", "))
+	}
+	switch msg := "credentials of registries " + strings.Join(parts, "; "); {
+	case len(expired) != 0:
+		SetExpiringSoonCondition(newStatus, "CredentialsExpired", msg)
+	case len(expiring) != 0:
+		SetExpiringSoonCondition(newStatus, "CredentialsExpiringSoon", msg)
+	default:
+		ClearExpiringSoonCondition(newStatus)
+	}
+}
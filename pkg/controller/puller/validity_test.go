@@ -0,0 +1,207 @@
+package puller
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+func TestCheckValidity(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *metav1.Time {
+		t := metav1.NewTime(now.Add(d))
+		return &t
+	}
+	jwt := func(exp time.Duration) string {
+		claims := fmt.Sprintf(`{"exp":%d}`, now.Add(exp).Unix())
+		return "header." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".signature"
+	}
+	registry := func(server string, mutate func(r *pullerv1beta1.Registry)) pullerv1beta1.Registry {
+		r := pullerv1beta1.Registry{
+			Server: server,
+			Credentials: pullerv1beta1.RegistryCredentials{
+				Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "secret"},
+			},
+		}
+		mutate(&r)
+		return r
+	}
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name        string
+		registry    pullerv1beta1.Registry
+		warnings    []metav1.Duration
+		wantKept    bool
+		wantState   pullerv1beta1.CredentialsState
+		wantWarning time.Duration
+		wantNext    time.Duration
+	}{
+		{
+			name:     "no validity window",
+			registry: registry("a.corp", func(*pullerv1beta1.Registry) {}),
+			wantKept: true,
+		},
+		{
+			name: "not yet valid",
+			registry: registry("a.corp", func(r *pullerv1beta1.Registry) {
+				r.ValidFrom = at(time.Hour)
+			}),
+			wantState: pullerv1beta1.CredentialsStateNotYetValid,
+			wantNext:  time.Hour,
+		},
+		{
+			name: "valid until the first warning",
+			registry: registry("a.corp", func(r *pullerv1beta1.Registry) {
+				r.ValidFrom = at(-time.Hour)
+				r.ExpiresAt = at(30 * day)
+			}),
+			wantKept:  true,
+			wantState: pullerv1beta1.CredentialsStateValid,
+			wantNext:  23 * day,
+		},
+		{
+			name: "within the 7 days warning",
+			registry: registry("a.corp", func(r *pullerv1beta1.Registry) {
+				r.ExpiresAt = at(3 * day)
+			}),
+			wantKept:    true,
+			wantState:   pullerv1beta1.CredentialsStateExpiringSoon,
+			wantWarning: 7 * day,
+			wantNext:    2 * day,
+		},
+		{
+			name: "within the 1 day warning",
+			registry: registry("a.corp", func(r *pullerv1beta1.Registry) {
+				r.ExpiresAt = at(12 * time.Hour)
+			}),
+			wantKept:    true,
+			wantState:   pullerv1beta1.CredentialsStateExpiringSoon,
+			wantWarning: day,
+			wantNext:    12 * time.Hour,
+		},
+		{
+			name: "custom warnings",
+			registry: registry("a.corp", func(r *pullerv1beta1.Registry) {
+				r.ExpiresAt = at(3 * day)
+			}),
+			warnings:  []metav1.Duration{{Duration: time.Hour}},
+			wantKept:  true,
+			wantState: pullerv1beta1.CredentialsStateValid,
+			wantNext:  3*day - time.Hour,
+		},
+		{
+			name: "expired",
+			registry: registry("a.corp", func(r *pullerv1beta1.Registry) {
+				r.ExpiresAt = at(0)
+			}),
+			wantState: pullerv1beta1.CredentialsStateExpired,
+		},
+		{
+			name: "expired JWT password",
+			registry: registry("a.corp", func(r *pullerv1beta1.Registry) {
+				r.Credentials.Basic.Password = jwt(-time.Hour)
+			}),
+			wantState: pullerv1beta1.CredentialsStateExpired,
+		},
+		{
+			name: "spec bound over the JWT claim",
+			registry: registry("a.corp", func(r *pullerv1beta1.Registry) {
+				r.Credentials = pullerv1beta1.RegistryCredentials{
+					Token: &pullerv1beta1.TokenCredentials{RegistryToken: jwt(-time.Hour)},
+				}
+				r.ExpiresAt = at(30 * day)
+			}),
+			wantKept:  true,
+			wantState: pullerv1beta1.CredentialsStateValid,
+			wantNext:  23 * day,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puller := &pullerv1beta1.Puller{
+				Spec: pullerv1beta1.PullerSpec{
+					Registries:     []pullerv1beta1.Registry{tt.registry},
+					ExpiryWarnings: tt.warnings,
+				},
+			}
+			valid, statuses, next := checkValidity(puller, puller, now)
+			if kept := len(valid.Spec.Registries) == 1; kept != tt.wantKept {
+				t.Errorf("checkValidity() kept the registry %v, want %v", kept, tt.wantKept)
+			}
+			if tt.wantState == "" {
+				if len(statuses) != 0 {
+					t.Errorf("checkValidity() statuses = %+v, want none", statuses)
+				}
+			} else {
+				if len(statuses) != 1 {
+					t.Fatalf("checkValidity() statuses = %+v, want one", statuses)
+				}
+				if statuses[0].State != tt.wantState {
+					t.Errorf("checkValidity() state = %s, want %s", statuses[0].State, tt.wantState)
+				}
+				var warning time.Duration
+				if w := statuses[0].Warning; w != nil {
+					warning = w.Duration
+				}
+				if warning != tt.wantWarning {
+					t.Errorf("checkValidity() warning = %s, want %s", warning, tt.wantWarning)
+				}
+			}
+			if next != tt.wantNext {
+				t.Errorf("checkValidity() next change in %s, want %s", next, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestExpiredOutputCredentials(t *testing.T) {
+	expiresAt := metav1.NewTime(time.Now().Add(-time.Hour))
+	puller := &pullerv1beta1.Puller{
+		ObjectMeta: metav1.ObjectMeta{Name: "puller", UID: "uid"},
+		Spec: pullerv1beta1.PullerSpec{
+			Registries: []pullerv1beta1.Registry{{
+				Server: "https://Harbor.corp/charts",
+				Credentials: pullerv1beta1.RegistryCredentials{
+					Basic: &pullerv1beta1.BasicCredentials{Username: "user", Password: "secret"},
+				},
+				ExpiresAt: &expiresAt,
+			}},
+			Outputs: []pullerv1beta1.Output{
+				{Format: pullerv1beta1.OutputFormatDockerConfigJSON, Name: "pull"},
+				{Format: pullerv1beta1.OutputFormatFluxHelmRepository, Name: "flux", Server: "harbor.corp/charts"},
+				{Format: pullerv1beta1.OutputFormatArgoCDRepository, Name: "argo", Server: "harbor.corp/charts"},
+			},
+		},
+	}
+	rendered, _, _ := checkValidity(puller, puller, time.Now())
+	secrets, err := newTestController().desiredSecrets(rendered, "default")
+	if err != nil {
+		t.Fatalf("desiredSecrets() error = %v", err)
+	}
+	if len(secrets) != 3 {
+		t.Fatalf("desiredSecrets() = %d secrets, want the 3 outputs", len(secrets))
+	}
+	byName := map[string]map[string][]byte{}
+	for _, d := range secrets {
+		byName[d.secret.Name] = d.secret.Data
+	}
+	if got := string(byName["pull"][corev1.DockerConfigJsonKey]); got != `{"auths":{}}` {
+		t.Errorf("pull secret = %s, want no auths", got)
+	}
+	for _, name := range []string{"flux", "argo"} {
+		data := byName[name]
+		if len(data[corev1.BasicAuthUsernameKey]) != 0 || len(data[corev1.BasicAuthPasswordKey]) != 0 {
+			t.Errorf("%s secret holds the expired credentials %q:%q", name, data[corev1.BasicAuthUsernameKey], data[corev1.BasicAuthPasswordKey])
+		}
+	}
+	if got := string(byName["argo"]["url"]); got != "harbor.corp/charts" {
+		t.Errorf("argo secret url = %q, want harbor.corp/charts", got)
+	}
+}
@@ -18,14 +18,20 @@ limitations under the License.
 package v1alpha1
 
 import (
+	pullerv1alpha1 "github.com/puller-io/puller/pkg/apis/puller/v1alpha1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // PullerSpecApplyConfiguration represents an declarative configuration of the PullerSpec type for use
 // with apply.
 type PullerSpecApplyConfiguration struct {
-	Registries        []RegistryApplyConfiguration `json:"registries,omitempty"`
-	NamespaceAffinity *v1.LabelSelector            `json:"namespaceAffinity,omitempty"`
+	Registries        []RegistryApplyConfiguration      `json:"registries,omitempty"`
+	NamespaceAffinity *v1.LabelSelector                 `json:"namespaceAffinity,omitempty"`
+	SecretTemplate    *SecretTemplateApplyConfiguration `json:"secretTemplate,omitempty"`
+	ConflictPolicy    *pullerv1alpha1.ConflictPolicy    `json:"conflictPolicy,omitempty"`
+	DeletionPolicy    *pullerv1alpha1.DeletionPolicy    `json:"deletionPolicy,omitempty"`
+	Suspend           *bool                             `json:"suspend,omitempty"`
+	Mode              *pullerv1alpha1.Mode              `json:"mode,omitempty"`
 }
 
 // PullerSpecApplyConfiguration constructs an declarative configuration of the PullerSpec type for use with
@@ -54,3 +60,43 @@ func (b *PullerSpecApplyConfiguration) WithNamespaceAffinity(value v1.LabelSelec
 	b.NamespaceAffinity = &value
 	return b
 }
+
+// WithSecretTemplate sets the SecretTemplate field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the SecretTemplate field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithSecretTemplate(value *SecretTemplateApplyConfiguration) *PullerSpecApplyConfiguration {
+	b.SecretTemplate = value
+	return b
+}
+
+// WithConflictPolicy sets the ConflictPolicy field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ConflictPolicy field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithConflictPolicy(value pullerv1alpha1.ConflictPolicy) *PullerSpecApplyConfiguration {
+	b.ConflictPolicy = &value
+	return b
+}
+
+// WithDeletionPolicy sets the DeletionPolicy field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the DeletionPolicy field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithDeletionPolicy(value pullerv1alpha1.DeletionPolicy) *PullerSpecApplyConfiguration {
+	b.DeletionPolicy = &value
+	return b
+}
+
+// WithSuspend sets the Suspend field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Suspend field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithSuspend(value bool) *PullerSpecApplyConfiguration {
+	b.Suspend = &value
+	return b
+}
+
+// WithMode sets the Mode field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Mode field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithMode(value pullerv1alpha1.Mode) *PullerSpecApplyConfiguration {
+	b.Mode = &value
+	return b
+}
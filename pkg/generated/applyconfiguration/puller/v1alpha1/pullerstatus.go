@@ -24,7 +24,9 @@ import (
 // PullerStatusApplyConfiguration represents an declarative configuration of the PullerStatus type for use
 // with apply.
 type PullerStatusApplyConfiguration struct {
-	Conditions []v1.Condition `json:"conditions,omitempty"`
+	Conditions []v1.Condition              `json:"conditions,omitempty"`
+	Plan       *PlanApplyConfiguration     `json:"plan,omitempty"`
+	Progress   *ProgressApplyConfiguration `json:"progress,omitempty"`
 }
 
 // PullerStatusApplyConfiguration constructs an declarative configuration of the PullerStatus type for use with
@@ -42,3 +44,19 @@ func (b *PullerStatusApplyConfiguration) WithConditions(values ...v1.Condition)
 	}
 	return b
 }
+
+// WithPlan sets the Plan field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Plan field is set to the value of the last call.
+func (b *PullerStatusApplyConfiguration) WithPlan(value *PlanApplyConfiguration) *PullerStatusApplyConfiguration {
+	b.Plan = value
+	return b
+}
+
+// WithProgress sets the Progress field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Progress field is set to the value of the last call.
+func (b *PullerStatusApplyConfiguration) WithProgress(value *ProgressApplyConfiguration) *PullerStatusApplyConfiguration {
+	b.Progress = value
+	return b
+}
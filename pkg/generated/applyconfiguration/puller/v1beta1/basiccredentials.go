@@ -0,0 +1,65 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+// BasicCredentialsApplyConfiguration represents an declarative configuration of the BasicCredentials type for use
+// with apply.
+type BasicCredentialsApplyConfiguration struct {
+	Username *string `json:"username,omitempty"`
+	Password *string `json:"password,omitempty"`
+	Auth     *string `json:"auth,omitempty"`
+	Email    *string `json:"email,omitempty"`
+}
+
+// BasicCredentialsApplyConfiguration constructs an declarative configuration of the BasicCredentials type for use with
+// apply.
+func BasicCredentials() *BasicCredentialsApplyConfiguration {
+	return &BasicCredentialsApplyConfiguration{}
+}
+
+// WithUsername sets the Username field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Username field is set to the value of the last call.
+func (b *BasicCredentialsApplyConfiguration) WithUsername(value string) *BasicCredentialsApplyConfiguration {
+	b.Username = &value
+	return b
+}
+
+// WithPassword sets the Password field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Password field is set to the value of the last call.
+func (b *BasicCredentialsApplyConfiguration) WithPassword(value string) *BasicCredentialsApplyConfiguration {
+	b.Password = &value
+	return b
+}
+
+// WithAuth sets the Auth field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Auth field is set to the value of the last call.
+func (b *BasicCredentialsApplyConfiguration) WithAuth(value string) *BasicCredentialsApplyConfiguration {
+	b.Auth = &value
+	return b
+}
+
+// WithEmail sets the Email field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Email field is set to the value of the last call.
+func (b *BasicCredentialsApplyConfiguration) WithEmail(value string) *BasicCredentialsApplyConfiguration {
+	b.Email = &value
+	return b
+}
@@ -0,0 +1,60 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+// OutputApplyConfiguration represents an declarative configuration of the Output type for use
+// with apply.
+type OutputApplyConfiguration struct {
+	Format *v1beta1.OutputFormat `json:"format,omitempty"`
+	Name   *string               `json:"name,omitempty"`
+	Server *string               `json:"server,omitempty"`
+}
+
+// OutputApplyConfiguration constructs an declarative configuration of the Output type for use with
+// apply.
+func Output() *OutputApplyConfiguration {
+	return &OutputApplyConfiguration{}
+}
+
+// WithFormat sets the Format field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Format field is set to the value of the last call.
+func (b *OutputApplyConfiguration) WithFormat(value v1beta1.OutputFormat) *OutputApplyConfiguration {
+	b.Format = &value
+	return b
+}
+
+// WithName sets the Name field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Name field is set to the value of the last call.
+func (b *OutputApplyConfiguration) WithName(value string) *OutputApplyConfiguration {
+	b.Name = &value
+	return b
+}
+
+// WithServer sets the Server field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Server field is set to the value of the last call.
+func (b *OutputApplyConfiguration) WithServer(value string) *OutputApplyConfiguration {
+	b.Server = &value
+	return b
+}
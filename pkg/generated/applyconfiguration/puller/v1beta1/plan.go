@@ -0,0 +1,61 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+// PlanApplyConfiguration represents an declarative configuration of the Plan type for use
+// with apply.
+type PlanApplyConfiguration struct {
+	ObservedGeneration *int64                            `json:"observedGeneration,omitempty"`
+	Total              *int                              `json:"total,omitempty"`
+	Changes            []PlannedChangeApplyConfiguration `json:"changes,omitempty"`
+}
+
+// PlanApplyConfiguration constructs an declarative configuration of the Plan type for use with
+// apply.
+func Plan() *PlanApplyConfiguration {
+	return &PlanApplyConfiguration{}
+}
+
+// WithObservedGeneration sets the ObservedGeneration field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ObservedGeneration field is set to the value of the last call.
+func (b *PlanApplyConfiguration) WithObservedGeneration(value int64) *PlanApplyConfiguration {
+	b.ObservedGeneration = &value
+	return b
+}
+
+// WithTotal sets the Total field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Total field is set to the value of the last call.
+func (b *PlanApplyConfiguration) WithTotal(value int) *PlanApplyConfiguration {
+	b.Total = &value
+	return b
+}
+
+// WithChanges adds the given value to the Changes field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Changes field.
+func (b *PlanApplyConfiguration) WithChanges(values ...*PlannedChangeApplyConfiguration) *PlanApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithChanges")
+		}
+		b.Changes = append(b.Changes, *values[i])
+	}
+	return b
+}
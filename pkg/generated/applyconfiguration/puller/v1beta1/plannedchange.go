@@ -0,0 +1,69 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+)
+
+// PlannedChangeApplyConfiguration represents an declarative configuration of the PlannedChange type for use
+// with apply.
+type PlannedChangeApplyConfiguration struct {
+	Action    *v1beta1.PlanAction `json:"action,omitempty"`
+	Kind      *string             `json:"kind,omitempty"`
+	Namespace *string             `json:"namespace,omitempty"`
+	Name      *string             `json:"name,omitempty"`
+}
+
+// PlannedChangeApplyConfiguration constructs an declarative configuration of the PlannedChange type for use with
+// apply.
+func PlannedChange() *PlannedChangeApplyConfiguration {
+	return &PlannedChangeApplyConfiguration{}
+}
+
+// WithAction sets the Action field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Action field is set to the value of the last call.
+func (b *PlannedChangeApplyConfiguration) WithAction(value v1beta1.PlanAction) *PlannedChangeApplyConfiguration {
+	b.Action = &value
+	return b
+}
+
+// WithKind sets the Kind field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Kind field is set to the value of the last call.
+func (b *PlannedChangeApplyConfiguration) WithKind(value string) *PlannedChangeApplyConfiguration {
+	b.Kind = &value
+	return b
+}
+
+// WithNamespace sets the Namespace field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Namespace field is set to the value of the last call.
+func (b *PlannedChangeApplyConfiguration) WithNamespace(value string) *PlannedChangeApplyConfiguration {
+	b.Namespace = &value
+	return b
+}
+
+// WithName sets the Name field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Name field is set to the value of the last call.
+func (b *PlannedChangeApplyConfiguration) WithName(value string) *PlannedChangeApplyConfiguration {
+	b.Name = &value
+	return b
+}
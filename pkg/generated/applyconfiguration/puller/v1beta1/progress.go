@@ -0,0 +1,76 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+// ProgressApplyConfiguration represents an declarative configuration of the Progress type for use
+// with apply.
+type ProgressApplyConfiguration struct {
+	ObservedGeneration *int64   `json:"observedGeneration,omitempty"`
+	Namespace          *string  `json:"namespace,omitempty"`
+	Synced             *int     `json:"synced,omitempty"`
+	Total              *int     `json:"total,omitempty"`
+	Blocked            []string `json:"blocked,omitempty"`
+}
+
+// ProgressApplyConfiguration constructs an declarative configuration of the Progress type for use with
+// apply.
+func Progress() *ProgressApplyConfiguration {
+	return &ProgressApplyConfiguration{}
+}
+
+// WithObservedGeneration sets the ObservedGeneration field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ObservedGeneration field is set to the value of the last call.
+func (b *ProgressApplyConfiguration) WithObservedGeneration(value int64) *ProgressApplyConfiguration {
+	b.ObservedGeneration = &value
+	return b
+}
+
+// WithNamespace sets the Namespace field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Namespace field is set to the value of the last call.
+func (b *ProgressApplyConfiguration) WithNamespace(value string) *ProgressApplyConfiguration {
+	b.Namespace = &value
+	return b
+}
+
+// WithSynced sets the Synced field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Synced field is set to the value of the last call.
+func (b *ProgressApplyConfiguration) WithSynced(value int) *ProgressApplyConfiguration {
+	b.Synced = &value
+	return b
+}
+
+// WithTotal sets the Total field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Total field is set to the value of the last call.
+func (b *ProgressApplyConfiguration) WithTotal(value int) *ProgressApplyConfiguration {
+	b.Total = &value
+	return b
+}
+
+// WithBlocked adds the given value to the Blocked field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Blocked field.
+func (b *ProgressApplyConfiguration) WithBlocked(values ...string) *ProgressApplyConfiguration {
+	for i := range values {
+		b.Blocked = append(b.Blocked, values[i])
+	}
+	return b
+}
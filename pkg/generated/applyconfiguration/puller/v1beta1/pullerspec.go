@@ -0,0 +1,163 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PullerSpecApplyConfiguration represents an declarative configuration of the PullerSpec type for use
+// with apply.
+type PullerSpecApplyConfiguration struct {
+	Registries           []RegistryApplyConfiguration       `json:"registries,omitempty"`
+	NamespaceSelector    *v1.LabelSelector                  `json:"namespaceSelector,omitempty"`
+	SecretTemplate       *SecretTemplateApplyConfiguration  `json:"secretTemplate,omitempty"`
+	Outputs              []OutputApplyConfiguration         `json:"outputs,omitempty"`
+	Rotation             *RotationApplyConfiguration        `json:"rotation,omitempty"`
+	RolloutStrategy      *RolloutStrategyApplyConfiguration `json:"rolloutStrategy,omitempty"`
+	RevisionHistoryLimit *int32                             `json:"revisionHistoryLimit,omitempty"`
+	RollbackTo           *int64                             `json:"rollbackTo,omitempty"`
+	ExpiryWarnings       []v1.Duration                      `json:"expiryWarnings,omitempty"`
+	ConflictPolicy       *pullerv1beta1.ConflictPolicy      `json:"conflictPolicy,omitempty"`
+	DeletionPolicy       *pullerv1beta1.DeletionPolicy      `json:"deletionPolicy,omitempty"`
+	Suspend              *bool                              `json:"suspend,omitempty"`
+	Mode                 *pullerv1beta1.Mode                `json:"mode,omitempty"`
+}
+
+// PullerSpecApplyConfiguration constructs an declarative configuration of the PullerSpec type for use with
+// apply.
+func PullerSpec() *PullerSpecApplyConfiguration {
+	return &PullerSpecApplyConfiguration{}
+}
+
+// WithRegistries adds the given value to the Registries field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Registries field.
+func (b *PullerSpecApplyConfiguration) WithRegistries(values ...*RegistryApplyConfiguration) *PullerSpecApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithRegistries")
+		}
+		b.Registries = append(b.Registries, *values[i])
+	}
+	return b
+}
+
+// WithNamespaceSelector sets the NamespaceSelector field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the NamespaceSelector field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithNamespaceSelector(value v1.LabelSelector) *PullerSpecApplyConfiguration {
+	b.NamespaceSelector = &value
+	return b
+}
+
+// WithSecretTemplate sets the SecretTemplate field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the SecretTemplate field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithSecretTemplate(value *SecretTemplateApplyConfiguration) *PullerSpecApplyConfiguration {
+	b.SecretTemplate = value
+	return b
+}
+
+// WithOutputs adds the given value to the Outputs field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Outputs field.
+func (b *PullerSpecApplyConfiguration) WithOutputs(values ...*OutputApplyConfiguration) *PullerSpecApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithOutputs")
+		}
+		b.Outputs = append(b.Outputs, *values[i])
+	}
+	return b
+}
+
+// WithRotation sets the Rotation field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Rotation field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithRotation(value *RotationApplyConfiguration) *PullerSpecApplyConfiguration {
+	b.Rotation = value
+	return b
+}
+
+// WithRolloutStrategy sets the RolloutStrategy field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the RolloutStrategy field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithRolloutStrategy(value *RolloutStrategyApplyConfiguration) *PullerSpecApplyConfiguration {
+	b.RolloutStrategy = value
+	return b
+}
+
+// WithRevisionHistoryLimit sets the RevisionHistoryLimit field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the RevisionHistoryLimit field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithRevisionHistoryLimit(value int32) *PullerSpecApplyConfiguration {
+	b.RevisionHistoryLimit = &value
+	return b
+}
+
+// WithRollbackTo sets the RollbackTo field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the RollbackTo field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithRollbackTo(value int64) *PullerSpecApplyConfiguration {
+	b.RollbackTo = &value
+	return b
+}
+
+// WithExpiryWarnings adds the given value to the ExpiryWarnings field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the ExpiryWarnings field.
+func (b *PullerSpecApplyConfiguration) WithExpiryWarnings(values ...v1.Duration) *PullerSpecApplyConfiguration {
+	for i := range values {
+		b.ExpiryWarnings = append(b.ExpiryWarnings, values[i])
+	}
+	return b
+}
+
+// WithConflictPolicy sets the ConflictPolicy field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ConflictPolicy field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithConflictPolicy(value pullerv1beta1.ConflictPolicy) *PullerSpecApplyConfiguration {
+	b.ConflictPolicy = &value
+	return b
+}
+
+// WithDeletionPolicy sets the DeletionPolicy field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the DeletionPolicy field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithDeletionPolicy(value pullerv1beta1.DeletionPolicy) *PullerSpecApplyConfiguration {
+	b.DeletionPolicy = &value
+	return b
+}
+
+// WithSuspend sets the Suspend field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Suspend field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithSuspend(value bool) *PullerSpecApplyConfiguration {
+	b.Suspend = &value
+	return b
+}
+
+// WithMode sets the Mode field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Mode field is set to the value of the last call.
+func (b *PullerSpecApplyConfiguration) WithMode(value pullerv1beta1.Mode) *PullerSpecApplyConfiguration {
+	b.Mode = &value
+	return b
+}
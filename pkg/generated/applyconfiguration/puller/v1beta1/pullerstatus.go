@@ -0,0 +1,112 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PullerStatusApplyConfiguration represents an declarative configuration of the PullerStatus type for use
+// with apply.
+type PullerStatusApplyConfiguration struct {
+	ObservedGeneration *int64                             `json:"observedGeneration,omitempty"`
+	Revision           *int64                             `json:"revision,omitempty"`
+	TargetNamespaces   *int                               `json:"targetNamespaces,omitempty"`
+	Conditions         []v1.Condition                     `json:"conditions,omitempty"`
+	Plan               *PlanApplyConfiguration            `json:"plan,omitempty"`
+	Progress           *ProgressApplyConfiguration        `json:"progress,omitempty"`
+	Registries         []RegistryStatusApplyConfiguration `json:"registries,omitempty"`
+	Rollout            *RolloutStatusApplyConfiguration   `json:"rollout,omitempty"`
+}
+
+// PullerStatusApplyConfiguration constructs an declarative configuration of the PullerStatus type for use with
+// apply.
+func PullerStatus() *PullerStatusApplyConfiguration {
+	return &PullerStatusApplyConfiguration{}
+}
+
+// WithObservedGeneration sets the ObservedGeneration field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ObservedGeneration field is set to the value of the last call.
+func (b *PullerStatusApplyConfiguration) WithObservedGeneration(value int64) *PullerStatusApplyConfiguration {
+	b.ObservedGeneration = &value
+	return b
+}
+
+// WithRevision sets the Revision field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Revision field is set to the value of the last call.
+func (b *PullerStatusApplyConfiguration) WithRevision(value int64) *PullerStatusApplyConfiguration {
+	b.Revision = &value
+	return b
+}
+
+// WithTargetNamespaces sets the TargetNamespaces field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the TargetNamespaces field is set to the value of the last call.
+func (b *PullerStatusApplyConfiguration) WithTargetNamespaces(value int) *PullerStatusApplyConfiguration {
+	b.TargetNamespaces = &value
+	return b
+}
+
+// WithConditions adds the given value to the Conditions field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Conditions field.
+func (b *PullerStatusApplyConfiguration) WithConditions(values ...v1.Condition) *PullerStatusApplyConfiguration {
+	for i := range values {
+		b.Conditions = append(b.Conditions, values[i])
+	}
+	return b
+}
+
+// WithPlan sets the Plan field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Plan field is set to the value of the last call.
+func (b *PullerStatusApplyConfiguration) WithPlan(value *PlanApplyConfiguration) *PullerStatusApplyConfiguration {
+	b.Plan = value
+	return b
+}
+
+// WithProgress sets the Progress field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Progress field is set to the value of the last call.
+func (b *PullerStatusApplyConfiguration) WithProgress(value *ProgressApplyConfiguration) *PullerStatusApplyConfiguration {
+	b.Progress = value
+	return b
+}
+
+// WithRegistries adds the given value to the Registries field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Registries field.
+func (b *PullerStatusApplyConfiguration) WithRegistries(values ...*RegistryStatusApplyConfiguration) *PullerStatusApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithRegistries")
+		}
+		b.Registries = append(b.Registries, *values[i])
+	}
+	return b
+}
+
+// WithRollout sets the Rollout field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Rollout field is set to the value of the last call.
+func (b *PullerStatusApplyConfiguration) WithRollout(value *RolloutStatusApplyConfiguration) *PullerStatusApplyConfiguration {
+	b.Rollout = value
+	return b
+}
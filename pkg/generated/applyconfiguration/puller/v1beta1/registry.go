@@ -0,0 +1,69 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RegistryApplyConfiguration represents an declarative configuration of the Registry type for use
+// with apply.
+type RegistryApplyConfiguration struct {
+	Server      *string                                `json:"server,omitempty"`
+	Credentials *RegistryCredentialsApplyConfiguration `json:"credentials,omitempty"`
+	ValidFrom   *v1.Time                               `json:"validFrom,omitempty"`
+	ExpiresAt   *v1.Time                               `json:"expiresAt,omitempty"`
+}
+
+// RegistryApplyConfiguration constructs an declarative configuration of the Registry type for use with
+// apply.
+func Registry() *RegistryApplyConfiguration {
+	return &RegistryApplyConfiguration{}
+}
+
+// WithServer sets the Server field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Server field is set to the value of the last call.
+func (b *RegistryApplyConfiguration) WithServer(value string) *RegistryApplyConfiguration {
+	b.Server = &value
+	return b
+}
+
+// WithCredentials sets the Credentials field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Credentials field is set to the value of the last call.
+func (b *RegistryApplyConfiguration) WithCredentials(value *RegistryCredentialsApplyConfiguration) *RegistryApplyConfiguration {
+	b.Credentials = value
+	return b
+}
+
+// WithValidFrom sets the ValidFrom field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ValidFrom field is set to the value of the last call.
+func (b *RegistryApplyConfiguration) WithValidFrom(value v1.Time) *RegistryApplyConfiguration {
+	b.ValidFrom = &value
+	return b
+}
+
+// WithExpiresAt sets the ExpiresAt field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ExpiresAt field is set to the value of the last call.
+func (b *RegistryApplyConfiguration) WithExpiresAt(value v1.Time) *RegistryApplyConfiguration {
+	b.ExpiresAt = &value
+	return b
+}
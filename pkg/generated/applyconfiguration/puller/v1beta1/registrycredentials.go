@@ -0,0 +1,56 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+// RegistryCredentialsApplyConfiguration represents an declarative configuration of the RegistryCredentials type for use
+// with apply.
+type RegistryCredentialsApplyConfiguration struct {
+	Basic     *BasicCredentialsApplyConfiguration `json:"basic,omitempty"`
+	Token     *TokenCredentialsApplyConfiguration `json:"token,omitempty"`
+	SecretRef *SecretReferenceApplyConfiguration  `json:"secretRef,omitempty"`
+}
+
+// RegistryCredentialsApplyConfiguration constructs an declarative configuration of the RegistryCredentials type for use with
+// apply.
+func RegistryCredentials() *RegistryCredentialsApplyConfiguration {
+	return &RegistryCredentialsApplyConfiguration{}
+}
+
+// WithBasic sets the Basic field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Basic field is set to the value of the last call.
+func (b *RegistryCredentialsApplyConfiguration) WithBasic(value *BasicCredentialsApplyConfiguration) *RegistryCredentialsApplyConfiguration {
+	b.Basic = value
+	return b
+}
+
+// WithToken sets the Token field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Token field is set to the value of the last call.
+func (b *RegistryCredentialsApplyConfiguration) WithToken(value *TokenCredentialsApplyConfiguration) *RegistryCredentialsApplyConfiguration {
+	b.Token = value
+	return b
+}
+
+// WithSecretRef sets the SecretRef field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the SecretRef field is set to the value of the last call.
+func (b *RegistryCredentialsApplyConfiguration) WithSecretRef(value *SecretReferenceApplyConfiguration) *RegistryCredentialsApplyConfiguration {
+	b.SecretRef = value
+	return b
+}
@@ -0,0 +1,79 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RegistryStatusApplyConfiguration represents an declarative configuration of the RegistryStatus type for use
+// with apply.
+type RegistryStatusApplyConfiguration struct {
+	Server    *string                   `json:"server,omitempty"`
+	ValidFrom *v1.Time                  `json:"validFrom,omitempty"`
+	ExpiresAt *v1.Time                  `json:"expiresAt,omitempty"`
+	State     *v1beta1.CredentialsState `json:"state,omitempty"`
+	Warning   *v1.Duration              `json:"warning,omitempty"`
+}
+
+// RegistryStatusApplyConfiguration constructs an declarative configuration of the RegistryStatus type for use with
+// apply.
+func RegistryStatus() *RegistryStatusApplyConfiguration {
+	return &RegistryStatusApplyConfiguration{}
+}
+
+// WithServer sets the Server field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Server field is set to the value of the last call.
+func (b *RegistryStatusApplyConfiguration) WithServer(value string) *RegistryStatusApplyConfiguration {
+	b.Server = &value
+	return b
+}
+
+// WithValidFrom sets the ValidFrom field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ValidFrom field is set to the value of the last call.
+func (b *RegistryStatusApplyConfiguration) WithValidFrom(value v1.Time) *RegistryStatusApplyConfiguration {
+	b.ValidFrom = &value
+	return b
+}
+
+// WithExpiresAt sets the ExpiresAt field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ExpiresAt field is set to the value of the last call.
+func (b *RegistryStatusApplyConfiguration) WithExpiresAt(value v1.Time) *RegistryStatusApplyConfiguration {
+	b.ExpiresAt = &value
+	return b
+}
+
+// WithState sets the State field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the State field is set to the value of the last call.
+func (b *RegistryStatusApplyConfiguration) WithState(value v1beta1.CredentialsState) *RegistryStatusApplyConfiguration {
+	b.State = &value
+	return b
+}
+
+// WithWarning sets the Warning field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Warning field is set to the value of the last call.
+func (b *RegistryStatusApplyConfiguration) WithWarning(value v1.Duration) *RegistryStatusApplyConfiguration {
+	b.Warning = &value
+	return b
+}
@@ -0,0 +1,97 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RolloutStatusApplyConfiguration represents an declarative configuration of the RolloutStatus type for use
+// with apply.
+type RolloutStatusApplyConfiguration struct {
+	Revision          *string               `json:"revision,omitempty"`
+	Phase             *v1beta1.RolloutPhase `json:"phase,omitempty"`
+	Stage             *int32                `json:"stage,omitempty"`
+	StageStartTime    *v1.Time              `json:"stageStartTime,omitempty"`
+	UpdatedNamespaces *int                  `json:"updatedNamespaces,omitempty"`
+	TotalNamespaces   *int                  `json:"totalNamespaces,omitempty"`
+	Message           *string               `json:"message,omitempty"`
+}
+
+// RolloutStatusApplyConfiguration constructs an declarative configuration of the RolloutStatus type for use with
+// apply.
+func RolloutStatus() *RolloutStatusApplyConfiguration {
+	return &RolloutStatusApplyConfiguration{}
+}
+
+// WithRevision sets the Revision field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Revision field is set to the value of the last call.
+func (b *RolloutStatusApplyConfiguration) WithRevision(value string) *RolloutStatusApplyConfiguration {
+	b.Revision = &value
+	return b
+}
+
+// WithPhase sets the Phase field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Phase field is set to the value of the last call.
+func (b *RolloutStatusApplyConfiguration) WithPhase(value v1beta1.RolloutPhase) *RolloutStatusApplyConfiguration {
+	b.Phase = &value
+	return b
+}
+
+// WithStage sets the Stage field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Stage field is set to the value of the last call.
+func (b *RolloutStatusApplyConfiguration) WithStage(value int32) *RolloutStatusApplyConfiguration {
+	b.Stage = &value
+	return b
+}
+
+// WithStageStartTime sets the StageStartTime field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the StageStartTime field is set to the value of the last call.
+func (b *RolloutStatusApplyConfiguration) WithStageStartTime(value v1.Time) *RolloutStatusApplyConfiguration {
+	b.StageStartTime = &value
+	return b
+}
+
+// WithUpdatedNamespaces sets the UpdatedNamespaces field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the UpdatedNamespaces field is set to the value of the last call.
+func (b *RolloutStatusApplyConfiguration) WithUpdatedNamespaces(value int) *RolloutStatusApplyConfiguration {
+	b.UpdatedNamespaces = &value
+	return b
+}
+
+// WithTotalNamespaces sets the TotalNamespaces field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the TotalNamespaces field is set to the value of the last call.
+func (b *RolloutStatusApplyConfiguration) WithTotalNamespaces(value int) *RolloutStatusApplyConfiguration {
+	b.TotalNamespaces = &value
+	return b
+}
+
+// WithMessage sets the Message field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Message field is set to the value of the last call.
+func (b *RolloutStatusApplyConfiguration) WithMessage(value string) *RolloutStatusApplyConfiguration {
+	b.Message = &value
+	return b
+}
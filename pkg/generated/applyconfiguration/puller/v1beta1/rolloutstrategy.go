@@ -0,0 +1,71 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RolloutStrategyApplyConfiguration represents an declarative configuration of the RolloutStrategy type for use
+// with apply.
+type RolloutStrategyApplyConfiguration struct {
+	Canary        *v1.LabelSelector `json:"canary,omitempty"`
+	SoakDuration  *v1.Duration      `json:"soakDuration,omitempty"`
+	Batches       []int32           `json:"batches,omitempty"`
+	BatchInterval *v1.Duration      `json:"batchInterval,omitempty"`
+}
+
+// RolloutStrategyApplyConfiguration constructs an declarative configuration of the RolloutStrategy type for use with
+// apply.
+func RolloutStrategy() *RolloutStrategyApplyConfiguration {
+	return &RolloutStrategyApplyConfiguration{}
+}
+
+// WithCanary sets the Canary field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Canary field is set to the value of the last call.
+func (b *RolloutStrategyApplyConfiguration) WithCanary(value v1.LabelSelector) *RolloutStrategyApplyConfiguration {
+	b.Canary = &value
+	return b
+}
+
+// WithSoakDuration sets the SoakDuration field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the SoakDuration field is set to the value of the last call.
+func (b *RolloutStrategyApplyConfiguration) WithSoakDuration(value v1.Duration) *RolloutStrategyApplyConfiguration {
+	b.SoakDuration = &value
+	return b
+}
+
+// WithBatches adds the given value to the Batches field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Batches field.
+func (b *RolloutStrategyApplyConfiguration) WithBatches(values ...int32) *RolloutStrategyApplyConfiguration {
+	for i := range values {
+		b.Batches = append(b.Batches, values[i])
+	}
+	return b
+}
+
+// WithBatchInterval sets the BatchInterval field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the BatchInterval field is set to the value of the last call.
+func (b *RolloutStrategyApplyConfiguration) WithBatchInterval(value v1.Duration) *RolloutStrategyApplyConfiguration {
+	b.BatchInterval = &value
+	return b
+}
@@ -0,0 +1,52 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RotationApplyConfiguration represents an declarative configuration of the Rotation type for use
+// with apply.
+type RotationApplyConfiguration struct {
+	Strategy    *v1beta1.RotationStrategy `json:"strategy,omitempty"`
+	GracePeriod *v1.Duration              `json:"gracePeriod,omitempty"`
+}
+
+// RotationApplyConfiguration constructs an declarative configuration of the Rotation type for use with
+// apply.
+func Rotation() *RotationApplyConfiguration {
+	return &RotationApplyConfiguration{}
+}
+
+// WithStrategy sets the Strategy field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Strategy field is set to the value of the last call.
+func (b *RotationApplyConfiguration) WithStrategy(value v1beta1.RotationStrategy) *RotationApplyConfiguration {
+	b.Strategy = &value
+	return b
+}
+
+// WithGracePeriod sets the GracePeriod field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the GracePeriod field is set to the value of the last call.
+func (b *RotationApplyConfiguration) WithGracePeriod(value v1.Duration) *RotationApplyConfiguration {
+	b.GracePeriod = &value
+	return b
+}
@@ -0,0 +1,56 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+// TokenCredentialsApplyConfiguration represents an declarative configuration of the TokenCredentials type for use
+// with apply.
+type TokenCredentialsApplyConfiguration struct {
+	Username      *string `json:"username,omitempty"`
+	IdentityToken *string `json:"identityToken,omitempty"`
+	RegistryToken *string `json:"registryToken,omitempty"`
+}
+
+// TokenCredentialsApplyConfiguration constructs an declarative configuration of the TokenCredentials type for use with
+// apply.
+func TokenCredentials() *TokenCredentialsApplyConfiguration {
+	return &TokenCredentialsApplyConfiguration{}
+}
+
+// WithUsername sets the Username field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Username field is set to the value of the last call.
+func (b *TokenCredentialsApplyConfiguration) WithUsername(value string) *TokenCredentialsApplyConfiguration {
+	b.Username = &value
+	return b
+}
+
+// WithIdentityToken sets the IdentityToken field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the IdentityToken field is set to the value of the last call.
+func (b *TokenCredentialsApplyConfiguration) WithIdentityToken(value string) *TokenCredentialsApplyConfiguration {
+	b.IdentityToken = &value
+	return b
+}
+
+// WithRegistryToken sets the RegistryToken field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the RegistryToken field is set to the value of the last call.
+func (b *TokenCredentialsApplyConfiguration) WithRegistryToken(value string) *TokenCredentialsApplyConfiguration {
+	b.RegistryToken = &value
+	return b
+}
@@ -19,7 +19,9 @@ package applyconfiguration
 
 import (
 	v1alpha1 "github.com/puller-io/puller/pkg/apis/puller/v1alpha1"
+	v1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
 	pullerv1alpha1 "github.com/puller-io/puller/pkg/generated/applyconfiguration/puller/v1alpha1"
+	pullerv1beta1 "github.com/puller-io/puller/pkg/generated/applyconfiguration/puller/v1beta1"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -28,6 +30,12 @@ import (
 func ForKind(kind schema.GroupVersionKind) interface{} {
 	switch kind {
 	// Group=puller.io, Version=v1alpha1
+	case v1alpha1.SchemeGroupVersion.WithKind("Plan"):
+		return &pullerv1alpha1.PlanApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("PlannedChange"):
+		return &pullerv1alpha1.PlannedChangeApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("Progress"):
+		return &pullerv1alpha1.ProgressApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("Puller"):
 		return &pullerv1alpha1.PullerApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("PullerSpec"):
@@ -36,6 +44,44 @@ func ForKind(kind schema.GroupVersionKind) interface{} {
 		return &pullerv1alpha1.PullerStatusApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("Registry"):
 		return &pullerv1alpha1.RegistryApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("SecretTemplate"):
+		return &pullerv1alpha1.SecretTemplateApplyConfiguration{}
+
+		// Group=puller.io, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithKind("BasicCredentials"):
+		return &pullerv1beta1.BasicCredentialsApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("Output"):
+		return &pullerv1beta1.OutputApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("Plan"):
+		return &pullerv1beta1.PlanApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("PlannedChange"):
+		return &pullerv1beta1.PlannedChangeApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("Progress"):
+		return &pullerv1beta1.ProgressApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("Puller"):
+		return &pullerv1beta1.PullerApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("PullerSpec"):
+		return &pullerv1beta1.PullerSpecApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("PullerStatus"):
+		return &pullerv1beta1.PullerStatusApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("Registry"):
+		return &pullerv1beta1.RegistryApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("RegistryCredentials"):
+		return &pullerv1beta1.RegistryCredentialsApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("RegistryStatus"):
+		return &pullerv1beta1.RegistryStatusApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("RolloutStatus"):
+		return &pullerv1beta1.RolloutStatusApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("RolloutStrategy"):
+		return &pullerv1beta1.RolloutStrategyApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("Rotation"):
+		return &pullerv1beta1.RotationApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("SecretReference"):
+		return &pullerv1beta1.SecretReferenceApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("SecretTemplate"):
+		return &pullerv1beta1.SecretTemplateApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("TokenCredentials"):
+		return &pullerv1beta1.TokenCredentialsApplyConfiguration{}
 
 	}
 	return nil
@@ -22,6 +22,7 @@ import (
 	"net/http"
 
 	pullerv1alpha1 "github.com/puller-io/puller/pkg/generated/clientset/versioned/typed/puller/v1alpha1"
+	pullerv1beta1 "github.com/puller-io/puller/pkg/generated/clientset/versioned/typed/puller/v1beta1"
 	discovery "k8s.io/client-go/discovery"
 	rest "k8s.io/client-go/rest"
 	flowcontrol "k8s.io/client-go/util/flowcontrol"
@@ -30,12 +31,14 @@ import (
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
 	PullerV1alpha1() pullerv1alpha1.PullerV1alpha1Interface
+	PullerV1beta1() pullerv1beta1.PullerV1beta1Interface
 }
 
 // Clientset contains the clients for groups.
 type Clientset struct {
 	*discovery.DiscoveryClient
 	pullerV1alpha1 *pullerv1alpha1.PullerV1alpha1Client
+	pullerV1beta1  *pullerv1beta1.PullerV1beta1Client
 }
 
 // PullerV1alpha1 retrieves the PullerV1alpha1Client
@@ -43,6 +46,11 @@ func (c *Clientset) PullerV1alpha1() pullerv1alpha1.PullerV1alpha1Interface {
 	return c.pullerV1alpha1
 }
 
+// PullerV1beta1 retrieves the PullerV1beta1Client
+func (c *Clientset) PullerV1beta1() pullerv1beta1.PullerV1beta1Interface {
+	return c.pullerV1beta1
+}
+
 // Discovery retrieves the DiscoveryClient
 func (c *Clientset) Discovery() discovery.DiscoveryInterface {
 	if c == nil {
@@ -91,6 +99,10 @@ func NewForConfigAndClient(c *rest.Config, httpClient *http.Client) (*Clientset,
 	if err != nil {
 		return nil, err
 	}
+	cs.pullerV1beta1, err = pullerv1beta1.NewForConfigAndClient(&configShallowCopy, httpClient)
+	if err != nil {
+		return nil, err
+	}
 
 	cs.DiscoveryClient, err = discovery.NewDiscoveryClientForConfigAndClient(&configShallowCopy, httpClient)
 	if err != nil {
@@ -113,6 +125,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 func New(c rest.Interface) *Clientset {
 	var cs Clientset
 	cs.pullerV1alpha1 = pullerv1alpha1.New(c)
+	cs.pullerV1beta1 = pullerv1beta1.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
 	return &cs
@@ -21,6 +21,8 @@ import (
 	clientset "github.com/puller-io/puller/pkg/generated/clientset/versioned"
 	pullerv1alpha1 "github.com/puller-io/puller/pkg/generated/clientset/versioned/typed/puller/v1alpha1"
 	fakepullerv1alpha1 "github.com/puller-io/puller/pkg/generated/clientset/versioned/typed/puller/v1alpha1/fake"
+	pullerv1beta1 "github.com/puller-io/puller/pkg/generated/clientset/versioned/typed/puller/v1beta1"
+	fakepullerv1beta1 "github.com/puller-io/puller/pkg/generated/clientset/versioned/typed/puller/v1beta1/fake"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
@@ -82,3 +84,8 @@ var (
 func (c *Clientset) PullerV1alpha1() pullerv1alpha1.PullerV1alpha1Interface {
 	return &fakepullerv1alpha1.FakePullerV1alpha1{Fake: &c.Fake}
 }
+
+// PullerV1beta1 retrieves the PullerV1beta1Client
+func (c *Clientset) PullerV1beta1() pullerv1beta1.PullerV1beta1Interface {
+	return &fakepullerv1beta1.FakePullerV1beta1{Fake: &c.Fake}
+}
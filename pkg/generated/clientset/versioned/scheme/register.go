@@ -19,6 +19,7 @@ package scheme
 
 import (
 	pullerv1alpha1 "github.com/puller-io/puller/pkg/apis/puller/v1alpha1"
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
@@ -31,6 +32,7 @@ var Codecs = serializer.NewCodecFactory(Scheme)
 var ParameterCodec = runtime.NewParameterCodec(Scheme)
 var localSchemeBuilder = runtime.SchemeBuilder{
 	pullerv1alpha1.AddToScheme,
+	pullerv1beta1.AddToScheme,
 }
 
 // AddToScheme adds all types of this clientset into the given scheme. This allows composition
@@ -0,0 +1,177 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+	json "encoding/json"
+	"fmt"
+
+	v1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	pullerv1beta1 "github.com/puller-io/puller/pkg/generated/applyconfiguration/puller/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakePullers implements PullerInterface
+type FakePullers struct {
+	Fake *FakePullerV1beta1
+}
+
+var pullersResource = v1beta1.SchemeGroupVersion.WithResource("pullers")
+
+var pullersKind = v1beta1.SchemeGroupVersion.WithKind("Puller")
+
+// Get takes name of the puller, and returns the corresponding puller object, and an error if there is any.
+func (c *FakePullers) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.Puller, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(pullersResource, name), &v1beta1.Puller{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Puller), err
+}
+
+// List takes label and field selectors, and returns the list of Pullers that match those selectors.
+func (c *FakePullers) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.PullerList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(pullersResource, pullersKind, opts), &v1beta1.PullerList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1beta1.PullerList{ListMeta: obj.(*v1beta1.PullerList).ListMeta}
+	for _, item := range obj.(*v1beta1.PullerList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested pullers.
+func (c *FakePullers) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(pullersResource, opts))
+}
+
+// Create takes the representation of a puller and creates it.  Returns the server's representation of the puller, and an error, if there is any.
+func (c *FakePullers) Create(ctx context.Context, puller *v1beta1.Puller, opts v1.CreateOptions) (result *v1beta1.Puller, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(pullersResource, puller), &v1beta1.Puller{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Puller), err
+}
+
+// Update takes the representation of a puller and updates it. Returns the server's representation of the puller, and an error, if there is any.
+func (c *FakePullers) Update(ctx context.Context, puller *v1beta1.Puller, opts v1.UpdateOptions) (result *v1beta1.Puller, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(pullersResource, puller), &v1beta1.Puller{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Puller), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakePullers) UpdateStatus(ctx context.Context, puller *v1beta1.Puller, opts v1.UpdateOptions) (*v1beta1.Puller, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateSubresourceAction(pullersResource, "status", puller), &v1beta1.Puller{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Puller), err
+}
+
+// Delete takes name of the puller and deletes it. Returns an error if one occurs.
+func (c *FakePullers) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteActionWithOptions(pullersResource, name, opts), &v1beta1.Puller{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakePullers) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(pullersResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1beta1.PullerList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched puller.
+func (c *FakePullers) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.Puller, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(pullersResource, name, pt, data, subresources...), &v1beta1.Puller{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Puller), err
+}
+
+// Apply takes the given apply declarative configuration, applies it and returns the applied puller.
+func (c *FakePullers) Apply(ctx context.Context, puller *pullerv1beta1.PullerApplyConfiguration, opts v1.ApplyOptions) (result *v1beta1.Puller, err error) {
+	if puller == nil {
+		return nil, fmt.Errorf("puller provided to Apply must not be nil")
+	}
+	data, err := json.Marshal(puller)
+	if err != nil {
+		return nil, err
+	}
+	name := puller.Name
+	if name == nil {
+		return nil, fmt.Errorf("puller.Name must be provided to Apply")
+	}
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(pullersResource, *name, types.ApplyPatchType, data), &v1beta1.Puller{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Puller), err
+}
+
+// ApplyStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating ApplyStatus().
+func (c *FakePullers) ApplyStatus(ctx context.Context, puller *pullerv1beta1.PullerApplyConfiguration, opts v1.ApplyOptions) (result *v1beta1.Puller, err error) {
+	if puller == nil {
+		return nil, fmt.Errorf("puller provided to Apply must not be nil")
+	}
+	data, err := json.Marshal(puller)
+	if err != nil {
+		return nil, err
+	}
+	name := puller.Name
+	if name == nil {
+		return nil, fmt.Errorf("puller.Name must be provided to Apply")
+	}
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(pullersResource, *name, types.ApplyPatchType, data, "status"), &v1beta1.Puller{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.Puller), err
+}
@@ -0,0 +1,88 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"context"
+	time "time"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	versioned "github.com/puller-io/puller/pkg/generated/clientset/versioned"
+	internalinterfaces "github.com/puller-io/puller/pkg/generated/informers/externalversions/internalinterfaces"
+	v1beta1 "github.com/puller-io/puller/pkg/generated/listers/puller/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// PullerInformer provides access to a shared informer and lister for
+// Pullers.
+type PullerInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1beta1.PullerLister
+}
+
+type pullerInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewPullerInformer constructs a new informer for Puller type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewPullerInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredPullerInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredPullerInformer constructs a new informer for Puller type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredPullerInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.PullerV1beta1().Pullers().List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.PullerV1beta1().Pullers().Watch(context.TODO(), options)
+			},
+		},
+		&pullerv1beta1.Puller{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *pullerInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredPullerInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *pullerInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&pullerv1beta1.Puller{}, f.defaultInformer)
+}
+
+func (f *pullerInformer) Lister() v1beta1.PullerLister {
+	return v1beta1.NewPullerLister(f.Informer().GetIndexer())
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// cacheSyncTimeout bounds the wait for the cache sync in a check.
+const cacheSyncTimeout = time.Second
+
+// CacheSynced fails until the informers of the cache are synced.
+func CacheSynced(c cache.Cache) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), cacheSyncTimeout)
+		defer cancel()
+		if !c.WaitForCacheSync(ctx) {
+			return errors.New("informer caches are not synced")
+		}
+		return nil
+	}
+}
+
+// APIServerProbe periodically makes a round-trip to the API server, its
+// check fails when no round-trip succeeded recently.
+type APIServerProbe struct {
+	Client discovery.DiscoveryInterface
+	// Period is the interval between two round-trips.
+	Period time.Duration
+	// MaxAge is the age over which the last successful round-trip is too old.
+	MaxAge time.Duration
+
+	mu          sync.RWMutex
+	lastSuccess time.Time
+	lastErr     error
+}
+
+var _ manager.LeaderElectionRunnable = &APIServerProbe{}
+
+// Start probes the API server until the context is done.
+func (p *APIServerProbe) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		_, err := p.Client.ServerVersion()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.lastErr = err
+		if err == nil {
+			p.lastSuccess = time.Now()
+		}
+	}, p.Period)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, every
+// replica checks its own access to the API server.
+func (p *APIServerProbe) NeedLeaderElection() bool {
+	return false
+}
+
+// Check implements healthz.Checker.
+func (p *APIServerProbe) Check(_ *http.Request) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.lastSuccess.IsZero() {
+		if p.lastErr != nil {
+			return fmt.Errorf("no successful API server round-trip yet: %w", p.lastErr)
+		}
+		return errors.New("no successful API server round-trip yet")
+	}
+	if age := time.Since(p.lastSuccess); age > p.MaxAge {
+		return fmt.Errorf("last successful API server round-trip %s ago: %v", age.Round(time.Second), p.lastErr)
+	}
+	return nil
+}
+
+// WorkqueueProgress detects the workqueues that have queued items but did
+// not finish any item for longer than Stall, from the workqueue metrics of
+// controller-runtime.
+type WorkqueueProgress struct {
+	Gatherer prometheus.Gatherer
+	// Stall is the time without progress after which a workqueue is stuck.
+	Stall time.Duration
+	// ProgressCounters are the names of the counters, labelled by the name
+	// of a workqueue, whose increase is progress of the workqueue as well,
+	// so that a long reconcile moving forward does not look stuck.
+	ProgressCounters []string
+
+	mu       sync.Mutex
+	progress map[string]queueProgress
+}
+
+// queueProgress records when a workqueue last made progress.
+type queueProgress struct {
+	done uint64
+	at   time.Time
+}
+
+// Check implements healthz.Checker.
+func (w *WorkqueueProgress) Check(_ *http.Request) error {
+	families, err := w.Gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather workqueue metrics: %w", err)
+	}
+	counters := make(map[string]bool, len(w.ProgressCounters))
+	for _, name := range w.ProgressCounters {
+		counters[name] = true
+	}
+	depth := map[string]float64{}
+	done := map[string]uint64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			name := ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "name" {
+					name = label.GetValue()
+				}
+			}
+			switch family.GetName() {
+			case "workqueue_depth":
+				depth[name] = metric.GetGauge().GetValue()
+			case "workqueue_work_duration_seconds":
+				done[name] += metric.GetHistogram().GetSampleCount()
+			default:
+				if counters[family.GetName()] {
+					done[name] += uint64(metric.GetCounter().GetValue())
+				}
+			}
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.progress == nil {
+		w.progress = map[string]queueProgress{}
+	}
+	now := time.Now()
+	var stuck []string
+	for name, d := range depth {
+		last, ok := w.progress[name]
+		if !ok || d == 0 || done[name] != last.done {
+			w.progress[name] = queueProgress{done: done[name], at: now}
+			continue
+		}
+		if since := now.Sub(last.at); since > w.Stall {
+			stuck = append(stuck, fmt.Sprintf("%s has %v queued items and finished none for %s", name, d, since.Round(time.Second)))
+		}
+	}
+	if len(stuck) != 0 {
+		sort.Strings(stuck)
+		return fmt.Errorf("workqueues are stuck: %v", stuck)
+	}
+	return nil
+}
+
+// DetailHandler serves the result of the checks with the failure reasons,
+// which the probe endpoints of the manager withhold. It is meant to be
+// served on a private endpoint, such as the metrics one.
+type DetailHandler struct {
+	Checks map[string]healthz.Checker
+}
+
+func (h *DetailHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	names := make([]string, 0, len(h.Checks))
+	for name := range h.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failed := false
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		if err := h.Checks[name](req); err != nil {
+			failed = true
+			lines = append(lines, fmt.Sprintf("[-]%s failed: %v", name, err))
+		} else {
+			lines = append(lines, fmt.Sprintf("[+]%s ok", name))
+		}
+	}
+
+	resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	resp.Header().Set("X-Content-Type-Options", "nosniff")
+	if failed {
+		resp.WriteHeader(http.StatusInternalServerError)
+	} else {
+		resp.WriteHeader(http.StatusOK)
+	}
+	_, verbose := req.URL.Query()["verbose"]
+	if !failed && !verbose {
+		fmt.Fprint(resp, "ok")
+		return
+	}
+	for _, line := range lines {
+		fmt.Fprintln(resp, line)
+	}
+	if failed {
+		fmt.Fprint(resp, "check failed\n")
+	} else {
+		fmt.Fprint(resp, "check passed\n")
+	}
+}
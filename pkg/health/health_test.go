@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestWorkqueueProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		depth    float64
+		progress func(done prometheus.Observer, fanOut prometheus.Counter)
+		wantErr  bool
+	}{
+		{
+			name:    "empty queue is not stuck",
+			wantErr: false,
+		},
+		{
+			name:    "queued items without progress are stuck",
+			depth:   1,
+			wantErr: true,
+		},
+		{
+			name:  "finished item is progress",
+			depth: 1,
+			progress: func(done prometheus.Observer, _ prometheus.Counter) {
+				done.Observe(1)
+			},
+			wantErr: false,
+		},
+		{
+			name:  "progress counter is progress",
+			depth: 1,
+			progress: func(_ prometheus.Observer, fanOut prometheus.Counter) {
+				fanOut.Inc()
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			depth := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "workqueue_depth"}, []string{"name"})
+			duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "workqueue_work_duration_seconds"}, []string{"name"})
+			fanOut := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "fanout_total"}, []string{"name"})
+			registry.MustRegister(depth, duration, fanOut)
+			depth.WithLabelValues("puller").Set(tt.depth)
+			duration.WithLabelValues("puller")
+			fanOut.WithLabelValues("puller")
+
+			w := &WorkqueueProgress{
+				Gatherer:         registry,
+				Stall:            time.Millisecond,
+				ProgressCounters: []string{"fanout_total"},
+			}
+			if err := w.Check(nil); err != nil {
+				t.Fatalf("first Check() error = %v", err)
+			}
+			time.Sleep(5 * time.Millisecond)
+			if tt.progress != nil {
+				tt.progress(duration.WithLabelValues("puller"), fanOut.WithLabelValues("puller"))
+			}
+			if err := w.Check(nil); (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
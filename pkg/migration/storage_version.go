@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// retryPeriod is the interval between two attempts of a failed migration.
+	retryPeriod = time.Minute
+	// pageSize is the number of objects listed at once.
+	pageSize = 500
+)
+
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// StorageVersionMigrator rewrites the objects of a CRD that may still be
+// stored in a previous version, and then records in the CRD that only the
+// storage version is stored, so that the previous versions can be removed.
+type StorageVersionMigrator struct {
+	Dynamic dynamic.Interface
+	// CRD is the name of the CRD.
+	CRD string
+	// Resource is the resource of the objects, in the storage version.
+	Resource schema.GroupVersionResource
+}
+
+var _ manager.LeaderElectionRunnable = &StorageVersionMigrator{}
+
+// Start migrates the objects, and retries until it succeeds or the context
+// is done.
+func (m *StorageVersionMigrator) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("storage-version-migrator")
+	_ = wait.PollUntilContextCancel(ctx, retryPeriod, true, func(ctx context.Context) (bool, error) {
+		if err := m.migrate(ctx); err != nil {
+			logger.Error(err, "failed to migrate the storage version", "crd", m.CRD)
+			return false, nil
+		}
+		return true, nil
+	})
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (m *StorageVersionMigrator) NeedLeaderElection() bool {
+	return true
+}
+
+func (m *StorageVersionMigrator) migrate(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("storage-version-migrator")
+	crd, err := m.Dynamic.Resource(crdResource).Get(ctx, m.CRD, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	stored, _, err := unstructured.NestedStringSlice(crd.Object, "status", "storedVersions")
+	if err != nil {
+		return err
+	}
+	if len(stored) == 1 && stored[0] == m.Resource.Version {
+		return nil
+	}
+
+	// an empty patch makes the API server write the object again, which
+	// encodes it in the storage version
+	migrated := 0
+	opts := metav1.ListOptions{Limit: pageSize}
+	for {
+		list, err := m.Dynamic.Resource(m.Resource).List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, item := range list.Items {
+			var resource dynamic.ResourceInterface = m.Dynamic.Resource(m.Resource)
+			if ns := item.GetNamespace(); ns != "" {
+				resource = m.Dynamic.Resource(m.Resource).Namespace(ns)
+			}
+			_, err := resource.Patch(ctx, item.GetName(), types.MergePatchType, []byte("{}"), metav1.PatchOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			migrated++
+		}
+		if opts.Continue = list.GetContinue(); opts.Continue == "" {
+			break
+		}
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"storedVersions": []string{m.Resource.Version},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := m.Dynamic.Resource(crdResource).Patch(ctx, m.CRD, types.MergePatchType, patch, metav1.PatchOptions{}, "status"); err != nil {
+		return err
+	}
+	logger.Info("Migrated the storage version", "crd", m.CRD, "version", m.Resource.Version, "previous", stored, "objects", migrated)
+	return nil
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// dockerHubAPIHost serves the registry API of Docker Hub.
+const dockerHubAPIHost = "registry-1.docker.io"
+
+// Credentials are the credentials of a registry, as held by a docker config.
+type Credentials struct {
+	Username      string
+	Password      string
+	IdentityToken string
+	RegistryToken string
+}
+
+// RejectedError reports credentials the registry refused.
+type RejectedError struct {
+	Server string
+	Status int
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("registry %s rejected the credentials: %s", e.Server, http.StatusText(e.Status))
+}
+
+// IsRejected reports whether the error is a refusal of the credentials by
+// the registry, rather than a failure to reach it.
+func IsRejected(err error) bool {
+	var rejected *RejectedError
+	return errors.As(err, &rejected)
+}
+
+// Checker checks credentials against the registries, with the
+// authentication of the Docker Registry HTTP API V2.
+type Checker struct {
+	Client *http.Client
+}
+
+// Check authenticates to the registry of the server with the credentials.
+// The servers with wildcard labels match several registries and are not
+// checked. A RejectedError is returned when the registry refuses the
+// credentials, any other error means the check could not complete.
+func (c *Checker) Check(ctx context.Context, server string, creds Credentials) error {
+	host, _ := SplitServer(NormalizeServer(server))
+	if strings.HasPrefix(host, Wildcard+".") {
+		return nil
+	}
+	if dockerHubAliases[strings.TrimSuffix(host, ":443")] {
+		host = dockerHubAPIHost
+	}
+	endpoint := "https://" + host + "/v2/"
+
+	header := ""
+	if creds.RegistryToken != "" {
+		header = "Bearer " + creds.RegistryToken
+	}
+	resp, err := c.do(ctx, http.MethodGet, endpoint, header, nil)
+	if err != nil {
+		return err
+	}
+	status, challenge := resp.StatusCode, resp.Header.Get("WWW-Authenticate")
+	switch {
+	case status == http.StatusOK:
+		return nil
+	case status != http.StatusUnauthorized:
+		return fmt.Errorf("registry %s answered %s", server, resp.Status)
+	case creds.RegistryToken != "":
+		return &RejectedError{Server: server, Status: status}
+	}
+
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case "basic":
+		resp, err := c.do(ctx, http.MethodGet, endpoint, basicAuth(creds.Username, creds.Password), nil)
+		if err != nil {
+			return err
+		}
+		return checkStatus(server, resp)
+	case "bearer":
+		return c.checkToken(ctx, server, params, creds)
+	}
+	return fmt.Errorf("registry %s asks for the unsupported authentication %q", server, challenge)
+}
+
+// checkToken asks the token server of the challenge for a token, with the
+// basic credentials or in exchange of the identity token.
+func (c *Checker) checkToken(ctx context.Context, server string, params map[string]string, creds Credentials) error {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("registry %s has an invalid token realm %q", server, params["realm"])
+	}
+	if creds.IdentityToken != "" {
+		form := url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {creds.IdentityToken},
+			"service":       {params["service"]},
+			"client_id":     {"puller"},
+		}
+		resp, err := c.do(ctx, http.MethodPost, realm.String(), "", strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		return checkStatus(server, resp)
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	realm.RawQuery = query.Encode()
+	resp, err := c.do(ctx, http.MethodGet, realm.String(), basicAuth(creds.Username, creds.Password), nil)
+	if err != nil {
+		return err
+	}
+	return checkStatus(server, resp)
+}
+
+// do sends a request and drains its response, only the status and the
+// headers are read.
+func (c *Checker) do(ctx context.Context, method, target, authorization string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+	return resp, nil
+}
+
+func checkStatus(server string, resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &RejectedError{Server: server, Status: resp.StatusCode}
+	}
+	return fmt.Errorf("registry %s answered %s", server, resp.Status)
+}
+
+func basicAuth(username, password string) string {
+	if username == "" && password == "" {
+		return ""
+	}
+	req := http.Request{Header: http.Header{}}
+	req.SetBasicAuth(username, password)
+	return req.Header.Get("Authorization")
+}
+
+// parseChallenge parses the scheme, in lower case, and the parameters of a
+// WWW-Authenticate header, such as
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io".
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = strings.TrimPrefix(strings.TrimSpace(value[end+2:]), ",")
+		} else {
+			v, next, _ := strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(v)
+			rest = next
+		}
+	}
+	return strings.ToLower(scheme), params
+}
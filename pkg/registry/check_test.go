@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChallenge(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		wantScheme string
+		wantParams map[string]string
+	}{
+		{
+			name:       "bearer",
+			header:     `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`,
+			wantScheme: "bearer",
+			wantParams: map[string]string{"realm": "https://auth.docker.io/token", "service": "registry.docker.io"},
+		},
+		{
+			name:       "basic",
+			header:     `Basic realm="Harbor"`,
+			wantScheme: "basic",
+			wantParams: map[string]string{"realm": "Harbor"},
+		},
+		{
+			name:       "spaces, case and unquoted values",
+			header:     `  BEARER Realm = "https://harbor.corp/service/token" , service=harbor-registry, scope="repository:a/b:pull,push"`,
+			wantScheme: "bearer",
+			wantParams: map[string]string{
+				"realm":   "https://harbor.corp/service/token",
+				"service": "harbor-registry",
+				"scope":   "repository:a/b:pull,push",
+			},
+		},
+		{
+			name:       "unterminated quote",
+			header:     `Bearer realm="https://auth.corp/token`,
+			wantScheme: "bearer",
+			wantParams: map[string]string{"realm": "https://auth.corp/token"},
+		},
+		{
+			name:       "scheme alone",
+			header:     "Negotiate",
+			wantScheme: "negotiate",
+			wantParams: map[string]string{},
+		},
+		{
+			name:       "empty",
+			wantParams: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme, params := parseChallenge(tt.header)
+			if scheme != tt.wantScheme {
+				t.Errorf("parseChallenge() scheme = %q, want %q", scheme, tt.wantScheme)
+			}
+			if !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("parseChallenge() params = %v, want %v", params, tt.wantParams)
+			}
+		})
+	}
+}
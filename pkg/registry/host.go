@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"strings"
+)
+
+// Wildcard matches any single label of a host.
+const Wildcard = "*"
+
+// NormalizeServer returns the canonical form of a registry server: without
+// scheme, surrounding spaces and trailing slash, and with a lower case host.
+func NormalizeServer(server string) string {
+	server = strings.TrimSpace(server)
+	if i := strings.Index(server, "://"); i >= 0 {
+		server = server[i+len("://"):]
+	}
+	server = strings.TrimRight(server, "/")
+	host, path := SplitServer(server)
+	host = strings.ToLower(host)
+	if path == "" {
+		return host
+	}
+	return host + "/" + path
+}
+
+// DockerHubKey is the key of the Docker Hub credentials in a docker config,
+// the kubelet matches the images of Docker Hub against it.
+const DockerHubKey = "https://index.docker.io/v1/"
+
+// dockerHubHost is the host the kubelet resolves the Docker Hub images to.
+const dockerHubHost = "index.docker.io"
+
+// dockerHubAliases are the hosts that serve Docker Hub.
+var dockerHubAliases = map[string]bool{
+	"docker.io":               true,
+	"index.docker.io":         true,
+	"registry-1.docker.io":    true,
+	"registry.hub.docker.com": true,
+}
+
+// ConfigKey returns the key of the credentials of a registry server in the
+// auths of a docker config, as the kubelet matches it against the images.
+// The port is part of the match and is kept, except the default https port
+// of Docker Hub. Docker Hub is keyed by DockerHubKey, or by its host when
+// the credentials are scoped to a repository path.
+func ConfigKey(server string) string {
+	host, path := SplitServer(NormalizeServer(server))
+	if dockerHubAliases[strings.TrimSuffix(host, ":443")] {
+		// v1 is the path of the legacy Docker Hub key itself
+		if path == "" || path == "v1" {
+			return DockerHubKey
+		}
+		host = dockerHubHost
+	}
+	if path == "" {
+		return host
+	}
+	return host + "/" + path
+}
+
+// SplitServer splits a registry server into its host, with the port if any,
+// and its repository path. The host may hold wildcard labels, such as
+// *.azurecr.io, and the path scopes the credentials to the repositories
+// under it, such as harbor.corp/team-a.
+func SplitServer(server string) (host, path string) {
+	host, path, _ = strings.Cut(server, "/")
+	return host, path
+}
+
+// MoreSpecific reports whether the docker config key a matches the images
+// more specifically than b: a longer repository path first, then fewer
+// wildcard labels, then more host labels. Equally specific keys are ordered
+// lexically, so that the order is stable.
+func MoreSpecific(a, b string) bool {
+	pa, wa, la := specificity(a)
+	pb, wb, lb := specificity(b)
+	switch {
+	case pa != pb:
+		return pa > pb
+	case wa != wb:
+		return wa < wb
+	case la != lb:
+		return la > lb
+	}
+	return a < b
+}
+
+// specificity returns the number of path segments, of wildcard labels and
+// of labels of the host of a docker config key.
+func specificity(key string) (segments, wildcards, labels int) {
+	if key == DockerHubKey {
+		return 0, 0, len(strings.Split(dockerHubHost, "."))
+	}
+	host, path := SplitServer(key)
+	if path != "" {
+		segments = len(strings.Split(path, "/"))
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == Wildcard {
+			wildcards++
+		}
+		labels++
+	}
+	return segments, wildcards, labels
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestNormalizeServer(t *testing.T) {
+	tests := []struct {
+		server string
+		want   string
+	}{
+		{server: "harbor.corp", want: "harbor.corp"},
+		{server: " https://Harbor.Corp/ ", want: "harbor.corp"},
+		{server: "http://harbor.corp:5000/team-a/", want: "harbor.corp:5000/team-a"},
+		{server: "HARBOR.corp/Team", want: "harbor.corp/Team"},
+		{server: "https://index.docker.io/v1/", want: "index.docker.io/v1"},
+		{server: "", want: ""},
+		{server: "https://", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.server, func(t *testing.T) {
+			if got := NormalizeServer(tt.server); got != tt.want {
+				t.Errorf("NormalizeServer(%q) = %q, want %q", tt.server, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigKey(t *testing.T) {
+	tests := []struct {
+		server string
+		want   string
+	}{
+		{server: "harbor.corp", want: "harbor.corp"},
+		{server: "https://harbor.corp:5000/team-a/", want: "harbor.corp:5000/team-a"},
+		{server: "*.azurecr.io", want: "*.azurecr.io"},
+		{server: "docker.io", want: DockerHubKey},
+		{server: "registry-1.docker.io:443", want: DockerHubKey},
+		{server: "https://index.docker.io/v1/", want: DockerHubKey},
+		{server: DockerHubKey, want: DockerHubKey},
+		{server: "docker.io/library", want: "index.docker.io/library"},
+		{server: "docker.io:5000", want: "docker.io:5000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.server, func(t *testing.T) {
+			if got := ConfigKey(tt.server); got != tt.want {
+				t.Errorf("ConfigKey(%q) = %q, want %q", tt.server, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoreSpecific(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{
+			name: "longer path first",
+			keys: []string{"harbor.corp", "harbor.corp/team-a/app", "harbor.corp/team-a"},
+			want: []string{"harbor.corp/team-a/app", "harbor.corp/team-a", "harbor.corp"},
+		},
+		{
+			name: "fewer wildcards first",
+			keys: []string{"*.*.azurecr.io", "*.azurecr.io", "corp.azurecr.io"},
+			want: []string{"corp.azurecr.io", "*.azurecr.io", "*.*.azurecr.io"},
+		},
+		{
+			name: "more labels first",
+			keys: []string{"corp", "eu.harbor.corp", "harbor.corp"},
+			want: []string{"eu.harbor.corp", "harbor.corp", "corp"},
+		},
+		{
+			name: "docker hub key is a host of three labels",
+			keys: []string{DockerHubKey, "index.docker.io/library", "docker.io"},
+			want: []string{"index.docker.io/library", DockerHubKey, "docker.io"},
+		},
+		{
+			name: "equally specific keys in lexical order",
+			keys: []string{"quay.io", "ghcr.io", "gcr.io"},
+			want: []string{"gcr.io", "ghcr.io", "quay.io"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := append([]string(nil), tt.keys...)
+			sort.Slice(got, func(i, j int) bool { return MoreSpecific(got[i], got[j]) })
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("sorted keys = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// TokenValidity returns the validity window a JWT exposes in its nbf and exp
+// claims, a zero time for a missing claim. ok is false when the token is
+// not a JWT or exposes neither claim. The signature is not verified, the
+// registry does.
+func TokenValidity(token string) (notBefore, expiresAt time.Time, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	claims := struct {
+		NotBefore *json.Number `json:"nbf"`
+		ExpiresAt *json.Number `json:"exp"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	if claims.NotBefore != nil {
+		if seconds, err := claims.NotBefore.Float64(); err == nil {
+			notBefore = time.Unix(int64(seconds), 0).UTC()
+		}
+	}
+	if claims.ExpiresAt != nil {
+		if seconds, err := claims.ExpiresAt.Float64(); err == nil {
+			expiresAt = time.Unix(int64(seconds), 0).UTC()
+		}
+	}
+	return notBefore, expiresAt, !notBefore.IsZero() || !expiresAt.IsZero()
+}
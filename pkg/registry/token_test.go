@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestTokenValidity(t *testing.T) {
+	jwt := func(claims string) string {
+		return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".signature"
+	}
+	tests := []struct {
+		name          string
+		token         string
+		wantNotBefore time.Time
+		wantExpiresAt time.Time
+		wantOK        bool
+	}{
+		{
+			name:          "both claims",
+			token:         jwt(`{"nbf":1700000000,"exp":1700003600}`),
+			wantNotBefore: time.Unix(1700000000, 0).UTC(),
+			wantExpiresAt: time.Unix(1700003600, 0).UTC(),
+			wantOK:        true,
+		},
+		{
+			name:          "expiry alone in fractional seconds",
+			token:         jwt(`{"exp":1700003600.5,"sub":"robot"}`),
+			wantExpiresAt: time.Unix(1700003600, 0).UTC(),
+			wantOK:        true,
+		},
+		{
+			name:          "padded payload",
+			token:         "header." + base64.URLEncoding.EncodeToString([]byte(`{"exp":1700003600}`)) + ".signature",
+			wantExpiresAt: time.Unix(1700003600, 0).UTC(),
+			wantOK:        true,
+		},
+		{
+			name:  "no validity claim",
+			token: jwt(`{"sub":"robot"}`),
+		},
+		{
+			name:  "claim that is not a number",
+			token: jwt(`{"exp":"tomorrow"}`),
+		},
+		{
+			name:  "payload that is not json",
+			token: jwt(`exp=1700003600`),
+		},
+		{
+			name:  "payload that is not base64",
+			token: "header.!!!.signature",
+		},
+		{
+			name:  "password",
+			token: "Harbor12345",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notBefore, expiresAt, ok := TokenValidity(tt.token)
+			if ok != tt.wantOK || !notBefore.Equal(tt.wantNotBefore) || !expiresAt.Equal(tt.wantExpiresAt) {
+				t.Errorf("TokenValidity() = %v, %v, %v, want %v, %v, %v",
+					notBefore, expiresAt, ok, tt.wantNotBefore, tt.wantExpiresAt, tt.wantOK)
+			}
+		})
+	}
+}
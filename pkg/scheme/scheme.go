@@ -6,6 +6,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 
 	pullerv1alpha1 "github.com/puller-io/puller/pkg/apis/puller/v1alpha1"
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
 )
 
 // Scheme holds the aggregated Kubernetes's schemes and extended schemes.
@@ -14,4 +15,6 @@ var Scheme = runtime.NewScheme()
 func init() {
 	utilruntime.Must(scheme.AddToScheme(Scheme))
 	utilruntime.Must(pullerv1alpha1.AddToScheme(Scheme))
+	utilruntime.Must(pullerv1beta1.AddToScheme(Scheme))
+	utilruntime.Must(Scheme.SetVersionPriority(pullerv1beta1.SchemeGroupVersion, pullerv1alpha1.SchemeGroupVersion))
 }
@@ -0,0 +1,348 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// GroupLabelKey is the label holding the shard group of a lease.
+	GroupLabelKey = "puller.io/shard-group"
+	// ShardLabelKey is the label holding the shard of a shard lease, member
+	// leases do not have it.
+	ShardLabelKey = "puller.io/shard"
+
+	// releaseTimeout bounds the release of the leases on shutdown.
+	releaseTimeout = 5 * time.Second
+)
+
+// ShardFor returns the shard of a key, out of the given number of shards.
+func ShardFor(key string, shards int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// Sharder splits the keys into a fixed number of shards and spreads the
+// shards over the active replicas. Every replica renews a member lease, and
+// holds the lease of at most its fair share of the shards: it releases the
+// extra shards when replicas join, and acquires the shards left by the
+// replicas that leave.
+type Sharder struct {
+	Client kubernetes.Interface
+	// Namespace holds the leases.
+	Namespace string
+	// Group prefixes the names of the leases, replicas of the same group
+	// share the shards.
+	Group string
+	// Identity is the unique identity of the replica.
+	Identity string
+	// Shards is the number of shards.
+	Shards int
+	// LeaseDuration is the duration a shard is held without renewal.
+	LeaseDuration time.Duration
+	// RetryPeriod is the interval between two renewals.
+	RetryPeriod time.Duration
+	// OnAcquired, if set, is called with the shards acquired by a renewal.
+	OnAcquired func(ctx context.Context, shards []int)
+
+	mu sync.RWMutex
+	// owned holds the expiry of the shards held by the replica.
+	owned map[int]time.Time
+}
+
+var _ manager.LeaderElectionRunnable = &Sharder{}
+
+// Owns reports whether the replica holds the shard of the key.
+func (s *Sharder) Owns(key string) bool {
+	return s.OwnsShard(ShardFor(key, s.Shards))
+}
+
+// OwnsShard reports whether the replica holds the shard.
+func (s *Sharder) OwnsShard(shard int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	expiry, ok := s.owned[shard]
+	return ok && time.Now().Before(expiry)
+}
+
+// Start renews the leases until the context is done, and then releases them.
+func (s *Sharder) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("sharder")
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.sync(ctx); err != nil {
+			logger.Error(err, "failed to renew shard leases")
+		}
+	}, s.RetryPeriod)
+
+	// release the leases, so that the other replicas do not have to wait
+	// for them to expire
+	releaseCtx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	s.release(releaseCtx)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, every
+// replica takes its share of the shards.
+func (s *Sharder) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Sharder) memberLeaseName() string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s.Identity))
+	return fmt.Sprintf("%s-member-%x", s.Group, h.Sum64())
+}
+
+func (s *Sharder) shardLeaseName(shard int) string {
+	return fmt.Sprintf("%s-shard-%d", s.Group, shard)
+}
+
+func (s *Sharder) sync(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("sharder")
+	leases := s.Client.CoordinationV1().Leases(s.Namespace)
+
+	now := time.Now()
+	if err := s.renewMember(ctx, now); err != nil {
+		return err
+	}
+	list, err := leases.List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{GroupLabelKey: s.Group}).String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	members := 0
+	shardLeases := make(map[int]*coordinationv1.Lease, s.Shards)
+	for i := range list.Items {
+		lease := &list.Items[i]
+		value, ok := lease.Labels[ShardLabelKey]
+		if !ok {
+			if !expired(lease, now) {
+				members++
+			} else if expired(lease, now.Add(-10*s.LeaseDuration)) {
+				// the replica is long gone
+				_ = leases.Delete(ctx, lease.Name, metav1.DeleteOptions{
+					Preconditions: &metav1.Preconditions{ResourceVersion: &lease.ResourceVersion},
+				})
+			}
+			continue
+		}
+		shard, err := strconv.Atoi(value)
+		if err != nil || shard < 0 || shard >= s.Shards {
+			continue
+		}
+		shardLeases[shard] = lease
+	}
+	if members == 0 {
+		// the member lease was just renewed, the list is stale
+		members = 1
+	}
+	target := (s.Shards + members - 1) / members
+
+	var held, free []int
+	for shard := 0; shard < s.Shards; shard++ {
+		lease := shardLeases[shard]
+		switch {
+		case lease != nil && holder(lease) == s.Identity:
+			held = append(held, shard)
+		case lease == nil || holder(lease) == "" || expired(lease, now):
+			free = append(free, shard)
+		}
+	}
+
+	var kept, acquired []int
+	for i, shard := range held {
+		if i >= target {
+			// keep only the fair share, so that the new replicas get theirs
+			s.forget(shard)
+			if err := s.releaseLease(ctx, shardLeases[shard]); err != nil {
+				logger.Error(err, "failed to release shard", "shard", shard)
+			} else {
+				logger.Info("Released shard", "shard", shard)
+			}
+			continue
+		}
+		if err := s.hold(ctx, shard, shardLeases[shard], now); err != nil {
+			s.forget(shard)
+			logger.Error(err, "failed to renew shard", "shard", shard)
+			continue
+		}
+		kept = append(kept, shard)
+	}
+	for _, shard := range free {
+		if len(kept)+len(acquired) >= target {
+			break
+		}
+		if err := s.hold(ctx, shard, shardLeases[shard], now); err != nil {
+			if !apierrors.IsConflict(err) && !apierrors.IsAlreadyExists(err) {
+				logger.Error(err, "failed to acquire shard", "shard", shard)
+			}
+			continue
+		}
+		logger.Info("Acquired shard", "shard", shard)
+		acquired = append(acquired, shard)
+	}
+	if len(acquired) != 0 && s.OnAcquired != nil {
+		s.OnAcquired(ctx, acquired)
+	}
+	return nil
+}
+
+// renewMember renews the member lease of the replica.
+func (s *Sharder) renewMember(ctx context.Context, now time.Time) error {
+	leases := s.Client.CoordinationV1().Leases(s.Namespace)
+	lease, err := leases.Get(ctx, s.memberLeaseName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = leases.Create(ctx, s.newLease(s.memberLeaseName(), nil, now), metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+	lease.Spec = s.newLease(lease.Name, nil, now).Spec
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+// hold acquires or renews the lease of a shard. The write is rejected if
+// another replica updated the lease in the meantime.
+func (s *Sharder) hold(ctx context.Context, shard int, lease *coordinationv1.Lease, now time.Time) error {
+	leases := s.Client.CoordinationV1().Leases(s.Namespace)
+	if lease == nil {
+		if _, err := leases.Create(ctx, s.newLease(s.shardLeaseName(shard), &shard, now), metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	} else {
+		updated := lease.DeepCopy()
+		updated.Spec = s.newLease(lease.Name, &shard, now).Spec
+		if holder(lease) == s.Identity {
+			updated.Spec.AcquireTime = lease.Spec.AcquireTime
+			updated.Spec.LeaseTransitions = lease.Spec.LeaseTransitions
+		} else {
+			updated.Spec.LeaseTransitions = pointer.Int32(pointer.Int32Deref(lease.Spec.LeaseTransitions, 0) + 1)
+		}
+		if _, err := leases.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.owned == nil {
+		s.owned = make(map[int]time.Time)
+	}
+	s.owned[shard] = now.Add(s.LeaseDuration)
+	return nil
+}
+
+// forget stops owning a shard, before its lease is released.
+func (s *Sharder) forget(shard int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.owned, shard)
+}
+
+// releaseLease clears the holder of a shard lease.
+func (s *Sharder) releaseLease(ctx context.Context, lease *coordinationv1.Lease) error {
+	updated := lease.DeepCopy()
+	updated.Spec.HolderIdentity = nil
+	updated.Spec.RenewTime = nil
+	_, err := s.Client.CoordinationV1().Leases(s.Namespace).Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// release gives up every shard and the membership of the replica.
+func (s *Sharder) release(ctx context.Context) {
+	logger := log.FromContext(ctx).WithName("sharder")
+	leases := s.Client.CoordinationV1().Leases(s.Namespace)
+
+	s.mu.Lock()
+	shards := make([]int, 0, len(s.owned))
+	for shard := range s.owned {
+		shards = append(shards, shard)
+	}
+	s.owned = nil
+	s.mu.Unlock()
+	sort.Ints(shards)
+
+	for _, shard := range shards {
+		lease, err := leases.Get(ctx, s.shardLeaseName(shard), metav1.GetOptions{})
+		if err == nil && holder(lease) == s.Identity {
+			err = s.releaseLease(ctx, lease)
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to release shard", "shard", shard)
+		}
+	}
+	err := leases.Delete(ctx, s.memberLeaseName(), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "failed to delete member lease")
+	}
+}
+
+func (s *Sharder) newLease(name string, shard *int, now time.Time) *coordinationv1.Lease {
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: s.Namespace,
+			Labels:    map[string]string{GroupLabelKey: s.Group},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       pointer.String(s.Identity),
+			LeaseDurationSeconds: pointer.Int32(int32(s.LeaseDuration / time.Second)),
+			AcquireTime:          &metav1.MicroTime{Time: now},
+			RenewTime:            &metav1.MicroTime{Time: now},
+			LeaseTransitions:     pointer.Int32(0),
+		},
+	}
+	if shard != nil {
+		lease.Labels[ShardLabelKey] = strconv.Itoa(*shard)
+	}
+	return lease
+}
+
+func holder(lease *coordinationv1.Lease) string {
+	return pointer.StringDeref(lease.Spec.HolderIdentity, "")
+}
+
+// expired reports whether the lease was not renewed within its duration.
+func expired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	duration := time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	return lease.Spec.RenewTime.Add(duration).Before(now)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestShardFor(t *testing.T) {
+	const shards = 8
+	counts := make([]int, shards)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("puller-%d", i)
+		shard := ShardFor(key, shards)
+		if shard < 0 || shard >= shards {
+			t.Fatalf("ShardFor(%q) = %d, out of [0, %d)", key, shard, shards)
+		}
+		if again := ShardFor(key, shards); again != shard {
+			t.Fatalf("ShardFor(%q) = %d then %d, want a stable shard", key, shard, again)
+		}
+		counts[shard]++
+	}
+	for shard, count := range counts {
+		if count == 0 {
+			t.Errorf("no key in shard %d", shard)
+		}
+	}
+	if got := ShardFor("puller", 1); got != 0 {
+		t.Errorf("ShardFor() of a single shard = %d, want 0", got)
+	}
+}
+
+func TestSharderFairShare(t *testing.T) {
+	const shards = 8
+	client := fake.NewSimpleClientset()
+	newSharder := func(identity string) *Sharder {
+		return &Sharder{
+			Client:        client,
+			Namespace:     "puller",
+			Group:         "puller",
+			Identity:      identity,
+			Shards:        shards,
+			LeaseDuration: time.Minute,
+			RetryPeriod:   time.Second,
+		}
+	}
+	owned := func(s *Sharder) []int {
+		var held []int
+		for shard := 0; shard < shards; shard++ {
+			if s.OwnsShard(shard) {
+				held = append(held, shard)
+			}
+		}
+		return held
+	}
+	sync := func(s *Sharder) {
+		t.Helper()
+		if err := s.sync(context.Background()); err != nil {
+			t.Fatalf("sync() of %s error = %v", s.Identity, err)
+		}
+	}
+
+	a, b := newSharder("a"), newSharder("b")
+	sync(a)
+	if got := len(owned(a)); got != shards {
+		t.Fatalf("single replica holds %d shards, want %d", got, shards)
+	}
+
+	// b joins: a releases the shards over its fair share, b acquires them
+	sync(b)
+	if got := len(owned(b)); got != 0 {
+		t.Fatalf("new replica holds %d shards before they are released, want 0", got)
+	}
+	sync(a)
+	sync(b)
+	heldA, heldB := owned(a), owned(b)
+	if len(heldA) != shards/2 || len(heldB) != shards/2 {
+		t.Fatalf("replicas hold %v and %v, want %d shards each", heldA, heldB, shards/2)
+	}
+	for _, shard := range heldA {
+		if b.OwnsShard(shard) {
+			t.Errorf("shard %d is held by both replicas", shard)
+		}
+	}
+
+	// a leaves: b acquires the released shards
+	a.release(context.Background())
+	if got := len(owned(a)); got != 0 {
+		t.Fatalf("released replica holds %d shards, want 0", got)
+	}
+	sync(b)
+	if got := len(owned(b)); got != shards {
+		t.Errorf("remaining replica holds %d shards, want %d", got, shards)
+	}
+}
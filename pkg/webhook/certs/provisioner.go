@@ -0,0 +1,340 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// CACertKey and CAKeyKey hold the CA in the secret of the certificate.
+	CACertKey = "ca.crt"
+	CAKeyKey  = "ca.key"
+
+	// servingCertDuration is the validity of the serving certificate.
+	servingCertDuration = 365 * 24 * time.Hour
+	// renewBefore is the remaining validity under which the serving
+	// certificate is renewed.
+	renewBefore = 30 * 24 * time.Hour
+	// checkPeriod is the interval between two checks of the certificate.
+	checkPeriod = 12 * time.Hour
+)
+
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// Provisioner provides the serving certificate of the webhook server. The
+// replicas share a CA and a certificate through a Secret, and the CA is
+// injected into the webhook configurations and the CRDs whose webhooks are
+// served by the service.
+type Provisioner struct {
+	Client  kubernetes.Interface
+	Dynamic dynamic.Interface
+	// Namespace holds the service and the secret.
+	Namespace string
+	// ServiceName is the name of the service of the webhook server.
+	ServiceName string
+	// SecretName is the name of the secret holding the certificate.
+	SecretName string
+	// CertDir is the directory the webhook server reads tls.crt and tls.key from.
+	CertDir string
+	// CRDs lists the CRDs converted by the webhook server.
+	CRDs []string
+}
+
+var _ manager.LeaderElectionRunnable = &Provisioner{}
+
+// Start renews the certificate until the context is done.
+func (p *Provisioner) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("cert-provisioner")
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := p.Provision(ctx); err != nil {
+			logger.Error(err, "failed to provision the webhook certificate")
+		}
+	}, checkPeriod)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, every
+// replica serves the webhooks.
+func (p *Provisioner) NeedLeaderElection() bool {
+	return false
+}
+
+// Provision writes the certificate to the certificate directory, after
+// creating or renewing it, and injects its CA.
+func (p *Provisioner) Provision(ctx context.Context) error {
+	secret, err := p.ensureSecret(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(p.CertDir, 0o700); err != nil {
+		return err
+	}
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		path := filepath.Join(p.CertDir, key)
+		if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, secret.Data[key]) {
+			continue
+		}
+		if err := os.WriteFile(path, secret.Data[key], 0o600); err != nil {
+			return err
+		}
+	}
+	return p.injectCA(ctx, secret.Data[CACertKey])
+}
+
+// ensureSecret returns the secret of a valid certificate. The replica that
+// finds it missing or expiring issues a new one, the others lose the race
+// on the write and read it.
+func (p *Provisioner) ensureSecret(ctx context.Context) (*corev1.Secret, error) {
+	secrets := p.Client.CoreV1().Secrets(p.Namespace)
+	for {
+		secret, err := secrets.Get(ctx, p.SecretName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: p.SecretName, Namespace: p.Namespace},
+				Type:       corev1.SecretTypeTLS,
+			}
+			if secret.Data, err = p.issue(nil); err != nil {
+				return nil, err
+			}
+			created, err := secrets.Create(ctx, secret, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				continue
+			}
+			return created, err
+		} else if err != nil {
+			return nil, err
+		}
+		if valid(secret.Data) {
+			return secret, nil
+		}
+		if secret.Data, err = p.issue(secret.Data); err != nil {
+			return nil, err
+		}
+		updated, err := secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		if apierrors.IsConflict(err) {
+			continue
+		}
+		return updated, err
+	}
+}
+
+// issue issues a serving certificate, signed by the CA of the data if
+// any, so that the injected CA does not change.
+func (p *Provisioner) issue(data map[string][]byte) (map[string][]byte, error) {
+	caCert, caKey, err := parseCA(data)
+	if err != nil {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		caCert, err = cert.NewSelfSignedCACert(cert.Config{CommonName: p.ServiceName + "-ca"}, key)
+		if err != nil {
+			return nil, err
+		}
+		caKey = key
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	host := fmt.Sprintf("%s.%s.svc", p.ServiceName, p.Namespace)
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames: []string{
+			p.ServiceName,
+			p.ServiceName + "." + p.Namespace,
+			host,
+			host + ".cluster.local",
+		},
+		NotBefore:   now.Add(-time.Hour).UTC(),
+		NotAfter:    now.Add(servingCertDuration).UTC(),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, caCert, key.Public(), caKey)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := keyutil.MarshalPrivateKeyToPEM(key)
+	if err != nil {
+		return nil, err
+	}
+	caKeyPEM, err := keyutil.MarshalPrivateKeyToPEM(caKey)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		corev1.TLSCertKey:       encodeCert(der),
+		corev1.TLSPrivateKeyKey: keyPEM,
+		CACertKey:               encodeCert(caCert.Raw),
+		CAKeyKey:                caKeyPEM,
+	}, nil
+}
+
+func encodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: der})
+}
+
+// parseCA parses the CA of the secret data.
+func parseCA(data map[string][]byte) (*x509.Certificate, crypto.Signer, error) {
+	certs, err := cert.ParseCertsPEM(data[CACertKey])
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(data[CAKeyKey])
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, errors.New("the CA key is not a signer")
+	}
+	if time.Now().Add(servingCertDuration).After(certs[0].NotAfter) {
+		return nil, nil, errors.New("the CA expires before the serving certificate")
+	}
+	return certs[0], signer, nil
+}
+
+// valid reports whether the certificate of the secret data is not about to
+// expire.
+func valid(data map[string][]byte) bool {
+	if len(data[corev1.TLSPrivateKeyKey]) == 0 || len(data[CACertKey]) == 0 {
+		return false
+	}
+	certs, err := cert.ParseCertsPEM(data[corev1.TLSCertKey])
+	if err != nil {
+		return false
+	}
+	return time.Now().Add(renewBefore).Before(certs[0].NotAfter)
+}
+
+// injectCA sets the CA bundle of the webhooks served by the service.
+func (p *Provisioner) injectCA(ctx context.Context, caBundle []byte) error {
+	var errs []error
+	admission := p.Client.AdmissionregistrationV1()
+	mutating, err := admission.MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range mutating.Items {
+		config := &mutating.Items[i]
+		changed := false
+		for j := range config.Webhooks {
+			changed = p.inject(config.Webhooks[j].ClientConfig.Service, &config.Webhooks[j].ClientConfig.CABundle, caBundle) || changed
+		}
+		if changed {
+			if _, err := admission.MutatingWebhookConfigurations().Update(ctx, config, metav1.UpdateOptions{}); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	validating, err := admission.ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range validating.Items {
+		config := &validating.Items[i]
+		changed := false
+		for j := range config.Webhooks {
+			changed = p.inject(config.Webhooks[j].ClientConfig.Service, &config.Webhooks[j].ClientConfig.CABundle, caBundle) || changed
+		}
+		if changed {
+			if _, err := admission.ValidatingWebhookConfigurations().Update(ctx, config, metav1.UpdateOptions{}); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	// the conversion webhook of the CRDs points to the service of the
+	// namespace the controller is deployed to
+	for _, name := range p.CRDs {
+		patch, err := json.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{
+				"conversion": map[string]interface{}{
+					"strategy": "Webhook",
+					"webhook": map[string]interface{}{
+						"clientConfig": map[string]interface{}{
+							"service": map[string]interface{}{
+								"namespace": p.Namespace,
+								"name":      p.ServiceName,
+								"path":      "/convert",
+							},
+							"caBundle": caBundle,
+						},
+						"conversionReviewVersions": []string{"v1"},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := p.Dynamic.Resource(crdResource).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// inject sets the CA bundle of a webhook served by the service, and reports
+// whether it changed.
+func (p *Provisioner) inject(service *admissionregistrationv1.ServiceReference, current *[]byte, caBundle []byte) bool {
+	if service == nil || service.Namespace != p.Namespace || service.Name != p.ServiceName || bytes.Equal(*current, caBundle) {
+		return false
+	}
+	*current = caBundle
+	return true
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The puller Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package puller
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	pullerv1beta1 "github.com/puller-io/puller/pkg/apis/puller/v1beta1"
+	"github.com/puller-io/puller/pkg/apis/puller/validation"
+)
+
+// +kubebuilder:webhook:path=/mutate-puller-io-v1beta1-puller,mutating=true,failurePolicy=fail,sideEffects=None,groups=puller.io,resources=pullers,verbs=create;update,versions=v1beta1,name=mpuller.puller.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-puller-io-v1beta1-puller,mutating=false,failurePolicy=fail,sideEffects=None,groups=puller.io,resources=pullers,verbs=create;update,versions=v1beta1,name=vpuller.puller.io,admissionReviewVersions=v1
+
+// Webhook defaults and validates the pullers on admission, with the same
+// validation the controller runs before a distribution.
+type Webhook struct {
+	// Scheme holds the defaulting functions of the pullers.
+	Scheme *runtime.Scheme
+}
+
+var (
+	_ admission.CustomDefaulter = &Webhook{}
+	_ admission.CustomValidator = &Webhook{}
+)
+
+// SetupWithManager registers the webhooks on the webhook server of the manager.
+func (w *Webhook) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&pullerv1beta1.Puller{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+// Default implements admission.CustomDefaulter.
+func (w *Webhook) Default(_ context.Context, obj runtime.Object) error {
+	puller, ok := obj.(*pullerv1beta1.Puller)
+	if !ok {
+		return fmt.Errorf("expected a Puller but got %T", obj)
+	}
+	w.Scheme.Default(puller)
+	return nil
+}
+
+// ValidateCreate implements admission.CustomValidator.
+func (w *Webhook) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, validate(obj)
+}
+
+// ValidateUpdate implements admission.CustomValidator.
+func (w *Webhook) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	puller, ok := newObj.(*pullerv1beta1.Puller)
+	if ok && !puller.DeletionTimestamp.IsZero() {
+		// let the controller remove the finalizer of an invalid puller
+		return nil, nil
+	}
+	return nil, validate(newObj)
+}
+
+// ValidateDelete implements admission.CustomValidator.
+func (w *Webhook) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validate(obj runtime.Object) error {
+	puller, ok := obj.(*pullerv1beta1.Puller)
+	if !ok {
+		return fmt.Errorf("expected a Puller but got %T", obj)
+	}
+	if errs := validation.ValidatePuller(puller); len(errs) != 0 {
+		return apierrors.NewInvalid(pullerv1beta1.Kind("Puller"), puller.Name, errs)
+	}
+	return nil
+}